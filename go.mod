@@ -1,3 +1,5 @@
 module synodict-go
 
-go 1.25.1
\ No newline at end of file
+go 1.25.1
+
+require golang.org/x/text v0.41.0
@@ -1,13 +1,132 @@
 package cmdpkg
 
 import (
+	"bufio"
+	"fmt"
+	"io"
 	"strings"
 	"synodict-go/internal/common"
+	"synodict-go/internal/corepkg"
 	"synodict-go/internal/iopkg"
-	"synodict-go/internal/structpkg"
+	"synodict-go/synodict"
 )
 
-var dict = structpkg.NewDict()
+const (
+	ExitOK = iota
+	ExitCommandError
+	ExitSyntaxError
+)
+
+// core gives the working file access to the change log of dict
+var core = corepkg.New()
+
+var dict = synodict.FromCore(core)
+
+var csvDelimiterNames = map[string]rune{
+	"semicolon": ';',
+	"comma":     ',',
+	"tab":       '\t',
+}
+
+func SetCsvOptions(delimiter string, header bool) error {
+	r, ok := csvDelimiterNames[delimiter]
+
+	if !ok {
+		return fmt.Errorf("unknown csv delimiter %q (expected semicolon, comma or tab)", delimiter)
+	}
+
+	return dict.SetCSVOptions(synodict.CSVOptions{Delimiter: r, Header: header})
+}
+
+// SetBackups keeps the last n versions of exported files and of the
+// working file.
+func SetBackups(n int) {
+	dict.SetBackups(n)
+}
+
+// SetWordPolicy configures the words the dictionary accepts from the
+// comma-separated -word-scripts and -word-chars flags.
+func SetWordPolicy(scripts, chars string, maxLength int) error {
+	policy := synodict.WordPolicy{MaxLength: maxLength}
+
+	for _, script := range strings.Split(scripts, ",") {
+		if script = strings.TrimSpace(script); script != "" {
+			policy.Scripts = append(policy.Scripts, script)
+		}
+	}
+
+	for _, char := range strings.Split(chars, ",") {
+		switch strings.TrimSpace(char) {
+		case "apostrophe":
+			policy.Apostrophes = true
+
+		case "period":
+			policy.Periods = true
+
+		case "digits":
+			policy.Digits = true
+
+		case "":
+
+		default:
+			return fmt.Errorf("unknown word character class %q (expected apostrophe, period or digits)", char)
+		}
+	}
+
+	if err := dict.SetWordPolicy(policy); err != nil {
+		return err
+	}
+
+	cmdRegexes = buildCmdRegexes(policy)
+
+	return nil
+}
+
+var normalFormNames = map[string]synodict.NormalForm{
+	"nfc":  synodict.NFC,
+	"nfkc": synodict.NFKC,
+	"none": synodict.NoNormalForm,
+}
+
+var caseFoldingNames = map[string]synodict.CaseFolding{
+	"lower":   synodict.FoldCase,
+	"turkish": synodict.FoldTurkish,
+	"none":    synodict.KeepCase,
+}
+
+func SetNormalization(form, fold string, keepSpaces, keepDisplay bool) error {
+	n := synodict.Normalization{KeepSpaces: keepSpaces, KeepDisplay: keepDisplay}
+	var ok bool
+
+	if n.Form, ok = normalFormNames[form]; !ok {
+		return fmt.Errorf("unknown normal form %q (expected nfc, nfkc or none)", form)
+	}
+
+	if n.Case, ok = caseFoldingNames[fold]; !ok {
+		return fmt.Errorf("unknown case folding %q (expected lower, turkish or none)", fold)
+	}
+
+	return dict.SetNormalization(n)
+}
+
+// Dictionary returns the dictionary the commands work on, loaded from the
+// working file if one is open.
+func Dictionary() *synodict.Dictionary {
+	return dict
+}
+
+func execute(cmd string, IORequestCh chan iopkg.IORequest) (result, []error) {
+	// cmd is canonical and has been validated, so it always tokenizes
+	tokens, _ := iopkg.Tokenize(cmd)
+	op := tokens[0].Value
+	args := []string{}
+
+	for _, token := range tokens[1:] {
+		args = append(args, token.Value)
+	}
+
+	return cmdHandlers[op](dict, args, IORequestCh)
+}
 
 func Run(IORequestCh chan iopkg.IORequest, exitCh chan common.Void) {
 	for {
@@ -26,15 +145,14 @@ func Run(IORequestCh chan iopkg.IORequest, exitCh chan common.Void) {
 				return
 			}
 
-			cmdParts := strings.Fields(cmd)
-			op := cmdParts[0]
-			args := []string{}
+			res, errs := execute(cmd, IORequestCh)
 
-			for i := 1; i < len(cmdParts); i++ {
-				args = append(args, strings.Trim(cmdParts[i], "\""))
+			if err := autosave(); err != nil {
+				errs = append(errs, err)
 			}
 
-			output := cmdHandlers[op](dict, args, IORequestCh)
+			output, errOutput := render(cmd, 0, res, errs)
+			output = append(output, errOutput...)
 
 			if len(output) > 0 {
 				IORequestCh <- iopkg.IORequest{
@@ -48,3 +166,79 @@ func Run(IORequestCh chan iopkg.IORequest, exitCh chan common.Void) {
 		}
 	}
 }
+
+func writeLines(w io.Writer, lines []string) {
+	for _, line := range lines {
+		fmt.Fprintln(w, line)
+	}
+}
+
+func RunBatch(input io.Reader, out, errOut io.Writer, keepGoing bool) int {
+	scanner := bufio.NewScanner(input)
+	exitCode := ExitOK
+
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		cmd, err := iopkg.Normalize(line)
+
+		if err != nil {
+			output, errOutput := render(line, lineNo, nil, []error{fmt.Errorf("%w: %w", errIncorrectSyntax, err)})
+			writeLines(out, output)
+			writeLines(errOut, errOutput)
+			exitCode = ExitSyntaxError
+
+			if !keepGoing {
+				return exitCode
+			}
+
+			continue
+		}
+
+		if cmd == iopkg.ExitCmd {
+			break
+		}
+
+		if !iopkg.ValidateByRegex(cmd, cmdRegexes) {
+			output, errOutput := render(cmd, lineNo, nil, []error{fmt.Errorf("%w: %s", errIncorrectSyntax, cmd)})
+			writeLines(out, output)
+			writeLines(errOut, errOutput)
+			exitCode = ExitSyntaxError
+
+			if !keepGoing {
+				return exitCode
+			}
+
+			continue
+		}
+
+		res, errs := execute(cmd, nil)
+
+		if err := autosave(); err != nil {
+			errs = append(errs, err)
+		}
+
+		output, errOutput := render(cmd, lineNo, res, errs)
+		writeLines(out, output)
+		writeLines(errOut, errOutput)
+
+		if len(errs) > 0 {
+			exitCode = max(exitCode, ExitCommandError)
+
+			if !keepGoing {
+				return exitCode
+			}
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		fmt.Fprintf(errOut, "error: %s\n", err)
+		return ExitCommandError
+	}
+
+	return exitCode
+}
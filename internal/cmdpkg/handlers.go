@@ -1,20 +1,106 @@
 package cmdpkg
 
 import (
+	"errors"
 	"fmt"
+	"math"
+	"os"
+	"slices"
+	"strconv"
 	"strings"
-	"synodict-go/internal/common"
 	"synodict-go/internal/iopkg"
 	"synodict-go/internal/stgpkg"
-	"synodict-go/internal/structpkg"
+	"synodict-go/synodict"
 )
 
 // helpers
+// flagValue returns the argument following the flag, if any.
+func flagValue(args []string, flag string) (string, bool) {
+	i := slices.Index(args, flag)
+
+	if i < 0 || i+1 == len(args) {
+		return "", false
+	}
+
+	return args[i+1], true
+}
+
+// countFlag reads a flag that takes a positive number, or returns fallback
+// when the flag is missing.
+func countFlag(args []string, flag string, fallback int) (int, error) {
+	value, ok := flagValue(args, flag)
+
+	if !ok {
+		return fallback, nil
+	}
+
+	n, err := strconv.Atoi(value)
+
+	if err != nil || n < 1 {
+		return 0, fmt.Errorf("%s must be a number from 1 to %d, got %s", flag, math.MaxInt, value)
+	}
+
+	return n, nil
+}
+
+func hasFlag(args []string, flag string) bool {
+	return slices.Contains(args, flag)
+}
+
+func positional(args []string) []string {
+	params := []string{}
+
+	for _, arg := range args {
+		if !strings.HasPrefix(arg, "--") {
+			params = append(params, arg)
+		}
+	}
+
+	return params
+}
+
+func confirm(args []string, IORequestCh chan iopkg.IORequest) (bool, []error) {
+	if hasFlag(args, "--force") {
+		return true, nil
+	}
+
+	if IORequestCh == nil {
+		return false, []error{errors.New("confirmation required: repeat the command with --force")}
+	}
+
+	return askUserChoice(IORequestCh), nil
+}
+
+func errorList(err error) []error {
+	if err == nil {
+		return nil
+	}
+
+	if joined, ok := err.(interface{ Unwrap() []error }); ok {
+		return joined.Unwrap()
+	}
+
+	return []error{err}
+}
+
+// unquote reads a canonical answer back into the text the user typed, with
+// quotes and escapes resolved.
+func unquote(response string) string {
+	tokens, _ := iopkg.Tokenize(response)
+	values := make([]string, len(tokens))
+
+	for i, token := range tokens {
+		values[i] = token.Value
+	}
+
+	return strings.Join(values, " ")
+}
+
 func askUserChoice(IORequestCh chan iopkg.IORequest) bool {
 	request := iopkg.IORequest{
 		Out:                 true,
 		In:                  true,
-		Prompts:             []string{"are you sure? this action cannot be undone (y/n or done)"},
+		Prompts:             []string{"are you sure? (y/n or done)"},
 		InCh:                make(chan string),
 		InValidationRegexes: []string{`^(y|n)$`},
 		InErrorPrompts:      []string{"type \"y\", \"n\" or \"done\""},
@@ -26,297 +112,408 @@ func askUserChoice(IORequestCh chan iopkg.IORequest) bool {
 	return response == "y"
 }
 
-func collectErrors(errs []error, log *[]string) {
-	if len(errs) > 0 {
-		for _, err := range errs {
-			*log = append(*log, "ERROR ~ "+err.Error())
-		}
-	}
+func describeSense(d *synodict.Dictionary, sense string) senseEntry {
+	return senseEntry{Word: sense, Gloss: d.Gloss(sense)}
 }
 
 // handlers
-func add(d *structpkg.Dict, args []string, _ chan iopkg.IORequest) []string {
-	err_log := []string{}
-	errs := d.AddSynonyms(args...)
-	collectErrors(errs, &err_log)
+func add(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	return nil, errorList(d.AddSynonyms(args...))
+}
 
-	return err_log
+func addWords(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	return nil, errorList(d.AddWords(args...))
 }
 
-func addWords(d *structpkg.Dict, args []string, _ chan iopkg.IORequest) []string {
-	err_log := []string{}
-	errs := d.AddWords(args...)
-	collectErrors(errs, &err_log)
+func remove(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	return nil, errorList(d.RemoveWords(args...))
+}
 
-	return err_log
+func unlink(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	return nil, errorList(d.Unlink(args[0], args[1]))
 }
 
-func remove(d *structpkg.Dict, args []string, _ chan iopkg.IORequest) []string {
-	err_log := []string{}
-	errs := d.RemoveWords(args...)
-	collectErrors(errs, &err_log)
+func unlinkClean(d *synodict.Dictionary, args []string, IORequestCh chan iopkg.IORequest) (result, []error) {
+	if !d.IsEmpty() {
+		if ok, errs := confirm(args, IORequestCh); !ok {
+			return nil, errs
+		}
+	}
 
-	return err_log
+	return nil, errorList(d.UnlinkAndCleanup(args[0], args[1]))
 }
 
-func unlink(d *structpkg.Dict, args []string, _ chan iopkg.IORequest) []string {
-	err_log := []string{}
-	errs := d.UnlinkSynonyms(args[0], args[1])
-	collectErrors(errs, &err_log)
-
-	return err_log
+func addAntonym(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	return nil, errorList(d.AddAntonyms(args[0], args[1]))
 }
 
-func unlinkClean(d *structpkg.Dict, args []string, IORequestCh chan iopkg.IORequest) []string {
-	if !d.IsEmpty() && !askUserChoice(IORequestCh) {
-		return []string{}
-	}
+func unlinkAntonym(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	return nil, errorList(d.UnlinkAntonyms(args[0], args[1]))
+}
 
-	err_log := []string{}
-	errs := d.UnlinkSynonymsAndCleanup(args[0], args[1])
-	collectErrors(errs, &err_log)
+func addBroader(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	return nil, errorList(d.AddBroader(args[0], args[1]))
+}
 
-	return err_log
+func unlinkBroader(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	return nil, errorList(d.UnlinkBroader(args[0], args[1]))
 }
 
-func check(d *structpkg.Dict, args []string, _ chan iopkg.IORequest) []string {
-	err_log := []string{}
-	result, errs := d.AreSynonyms(args[0], args[1])
-	collectErrors(errs, &err_log)
+func addSense(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	key, err := d.AddSense(args[0], strings.Join(args[1:], " "))
 
-	if len(errs) > 0 {
-		return err_log
+	if err != nil {
+		return nil, errorList(err)
 	}
 
-	if result {
-		return []string{"synonyms: yes"}
-	} else {
-		return []string{"synonyms: no"}
-	}
+	return messageResult{Message: fmt.Sprintf("added sense \"%s\"", key), Value: key}, nil
 }
 
-func checkDirect(d *structpkg.Dict, args []string, _ chan iopkg.IORequest) []string {
-	err_log := []string{}
-	result, errs := d.AreDirectSynonyms(args[0], args[1])
-	collectErrors(errs, &err_log)
+func setGloss(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	return nil, errorList(d.SetGloss(args[0], strings.Join(args[1:], " ")))
+}
 
-	if len(errs) > 0 {
-		return err_log
-	}
+func senses(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	result, err := d.Senses(args[0])
 
-	if result {
-		return []string{"direct-linked synonyms: yes"}
-	} else {
-		return []string{"direct-linked synonyms: no"}
+	if err != nil {
+		return nil, errorList(err)
 	}
-}
 
-func exists(d *structpkg.Dict, args []string, _ chan iopkg.IORequest) []string {
-	if d.WordExists(args[0]) {
-		return []string{"exists: yes"}
-	} else {
-		return []string{"exists: no"}
+	response := senseListResult{Word: args[0], Senses: []senseEntry{}}
+
+	for _, sense := range result {
+		response.Senses = append(response.Senses, describeSense(d, sense.Key))
 	}
+
+	return response, nil
 }
 
-func count(d *structpkg.Dict, args []string, _ chan iopkg.IORequest) []string {
-	result, err := d.SynonymCount(args[0])
+func check(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	result, err := d.SynonymousSenses(args[0], args[1])
 
 	if err != nil {
-		return []string{err.Error()}
+		return nil, errorList(err)
 	}
 
-	response := []string{}
+	response := flagResult{Name: "synonyms", Value: len(result) > 0}
+	sensesA, _ := d.Senses(args[0])
+	sensesB, _ := d.Senses(args[1])
+	response.showPairs = len(sensesA) > 1 || len(sensesB) > 1
 
-	if result > 1 {
-		response = append(response, fmt.Sprintf("word \"%s\" has %d synonyms", args[0], result))
-	} else if result == 1 {
-		response = append(response, fmt.Sprintf("word \"%s\" has 1 synonym", args[0]))
-	} else {
-		response = append(response, fmt.Sprintf("word \"%s\" has no synonyms yet", args[0]))
+	for _, pair := range result {
+		response.Pairs = append(
+			response.Pairs,
+			[2]senseEntry{describeSense(d, pair[0]), describeSense(d, pair[1])},
+		)
 	}
 
-	return response
+	return response, nil
 }
 
-func synonyms(d *structpkg.Dict, args []string, _ chan iopkg.IORequest) []string {
-	result, err := d.GetSynomyms(args[0])
+func checkDirect(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	result, err := d.AreDirectSynonyms(args[0], args[1])
 
 	if err != nil {
-		return []string{err.Error()}
+		return nil, errorList(err)
+	}
+
+	return flagResult{Name: "direct-linked synonyms", Value: result}, nil
+}
+
+func path(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	response := pathResult{
+		From:  args[0],
+		To:    args[1],
+		Paths: [][]string{},
+		all:   len(args) > 2 && args[2] == "--all-shortest",
 	}
 
-	response := []string{}
+	var err error
 
-	if len(result) > 0 {
-		response = append(
-			response,
-			fmt.Sprintf("word \"%s\" synonym list:", args[0]),
-		)
+	if response.all {
+		var result [][]string
+		result, err = d.AllPaths(args[0], args[1])
 
-		for i, s := range result {
-			response = append(
-				response,
-				fmt.Sprintf("%d) %s", i+1, s),
-			)
+		if len(result) > 0 {
+			response.Paths = result
 		}
 	} else {
-		response = append(
-			response,
-			fmt.Sprintf("word \"%s\" has no synonyms yet", args[0]),
-		)
+		var shortest []string
+		shortest, err = d.Path(args[0], args[1])
+
+		if len(shortest) > 0 {
+			response.Paths = append(response.Paths, shortest)
+		}
 	}
 
-	return response
+	if err != nil {
+		return nil, errorList(err)
+	}
+
+	return response, nil
+}
+
+func exists(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	return flagResult{Name: "exists", Value: d.Exists(args[0])}, nil
+}
+
+const suggestionLimit = 10
+
+func suggest(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	return listResult{
+		Word:  args[0],
+		Items: d.Suggest(args[0], suggestionLimit),
+		title: fmt.Sprintf("words similar to \"%s\":", args[0]),
+		empty: fmt.Sprintf("no words similar to \"%s\"", args[0]),
+	}, nil
+}
+
+func count(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	result, err := d.SynonymCount(args[0])
+
+	if err != nil {
+		return nil, errorList(err)
+	}
+
+	return countResult{Word: args[0], Count: result, unit: "synonym"}, nil
 }
 
-func directSynonyms(d *structpkg.Dict, args []string, _ chan iopkg.IORequest) []string {
-	result, err := d.GetDirectSynonyms(args[0])
+func synonyms(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	senses, err := d.Senses(args[0])
 
 	if err != nil {
-		return []string{err.Error()}
+		return nil, errorList(err)
 	}
 
-	response := []string{}
+	response := synonymsResult{Word: args[0], Senses: []senseSynonyms{}}
 
-	if len(result) > 0 {
-		response = append(
-			response,
-			fmt.Sprintf("word \"%s\" direct-linked synonym list:", args[0]),
-		)
+	for _, sense := range senses {
+		result, err := d.Synonyms(sense.Ref())
 
-		for i, s := range result {
-			response = append(
-				response,
-				fmt.Sprintf("%d) %s", i+1, s),
-			)
+		if err != nil {
+			return nil, []error{err}
 		}
-	} else {
-		response = append(
-			response,
-			fmt.Sprintf("word \"%s\" has no direct-linked synonyms yet", args[0]),
-		)
+
+		response.Senses = append(response.Senses, senseSynonyms{
+			Sense:    describeSense(d, sense.Key),
+			Synonyms: append([]string{}, result...),
+		})
 	}
 
-	return response
+	return response, nil
 }
 
-func countGroups(d *structpkg.Dict, args []string, _ chan iopkg.IORequest) []string {
-	count := len(d.GetSynonymGroups())
+func relationList(word, relation string, items []string, err error) (result, []error) {
+	if err != nil {
+		return nil, errorList(err)
+	}
 
-	switch count {
-	case 0:
-		return []string{"dictionary has no synonym groups yet"}
+	return listResult{
+		Word:  word,
+		Items: append([]string{}, items...),
+		title: fmt.Sprintf("word \"%s\" %s list:", word, relation),
+		empty: fmt.Sprintf("word \"%s\" has no %ss yet", word, relation),
+	}, nil
+}
 
-	case 1:
-		return []string{"dictionary contains 1 synonym group"}
+func directSynonyms(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	result, err := d.DirectSynonyms(args[0])
+	return relationList(args[0], "direct-linked synonym", result, err)
+}
 
-	default:
-		return []string{fmt.Sprintf("dictionary contains %d synonym groups", count)}
-	}
+func antonyms(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	result, err := d.Antonyms(args[0])
+	return relationList(args[0], "antonym", result, err)
 }
 
-func groups(d *structpkg.Dict, args []string, _ chan iopkg.IORequest) []string {
-	groups := d.GetSynonymGroups()
-	response := []string{}
+func broader(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	result, err := d.Broader(args[0])
+	return relationList(args[0], "broader term", result, err)
+}
 
-	if len(groups) == 0 {
-		response = append(response, "dictionary has no groups yet")
-		return response
-	}
+func narrower(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	result, err := d.Narrower(args[0])
+	return relationList(args[0], "narrower term", result, err)
+}
 
-	for i, group := range groups {
-		response = append(
-			response,
-			fmt.Sprintf("%d group", i+1),
-		)
+func countGroups(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	return countResult{Count: d.GroupCount(), unit: "synonym group"}, nil
+}
 
-		for j, word := range group {
-			response = append(
-				response,
-				fmt.Sprintf("%d) %s", j+1, word),
-			)
-		}
+func groups(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	response := groupsResult{Groups: [][]senseEntry{}}
 
-		if i < len(groups)-1 {
-			response = append(response, "")
+	for _, group := range d.Groups() {
+		entries := []senseEntry{}
+
+		for _, word := range group {
+			entries = append(entries, describeSense(d, word))
 		}
+
+		response.Groups = append(response.Groups, entries)
 	}
 
-	return response
+	return response, nil
+}
+
+func countWords(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	return countResult{Count: d.WordCount(), unit: "word"}, nil
 }
 
-func countWords(d *structpkg.Dict, args []string, _ chan iopkg.IORequest) []string {
-	count := len(d.GetWords())
+func words(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	return listResult{
+		Items: append([]string{}, d.Words()...),
+		empty: "dictionary has no words yet",
+	}, nil
+}
 
-	switch count {
-	case 0:
-		return []string{"dictionary has no words yet"}
+const searchPageSize = 20
 
-	case 1:
-		return []string{"dictionary contains 1 word"}
+func search(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	limit, err := countFlag(args, "--limit", searchPageSize)
 
-	default:
-		return []string{fmt.Sprintf("dictionary contains %d words", count)}
+	if err != nil {
+		return nil, []error{fmt.Errorf("search failed: %w", err)}
 	}
-}
 
-func words(d *structpkg.Dict, args []string, _ chan iopkg.IORequest) []string {
-	words := d.GetWords()
-	response := []string{}
+	page, err := countFlag(args, "--page", 1)
 
-	if len(words) == 0 {
-		response = append(response, "dictionary has no groups yet")
-		return response
+	if err != nil {
+		return nil, []error{fmt.Errorf("search failed: %w", err)}
 	}
 
-	for i, word := range words {
-		response = append(
-			response,
-			fmt.Sprintf("%d) %s", i+1, word),
-		)
+	if page-1 > math.MaxInt/limit {
+		return nil, []error{fmt.Errorf("search failed: page %d of %d words per page is out of range", page, limit)}
+	}
+
+	offset := (page - 1) * limit
+	found, err := d.Search(args[0], offset, limit)
+
+	if err != nil {
+		return nil, errorList(err)
 	}
 
-	return response
+	pages := 0
+
+	if found.Total > 0 {
+		pages = (found.Total-1)/limit + 1
+	}
+
+	return searchResult{
+		Query: args[0],
+		Words: append([]string{}, found.Words...),
+		Total: found.Total,
+		Page:  page,
+		Pages: pages,
+		first: offset + 1,
+	}, nil
 }
 
-func cleanup(d *structpkg.Dict, args []string, IORequestCh chan iopkg.IORequest) []string {
+func cleanup(d *synodict.Dictionary, args []string, IORequestCh chan iopkg.IORequest) (result, []error) {
 	if d.IsEmpty() {
-		return []string{"dictionary is already empty"}
+		return messageResult{Message: "dictionary is already empty"}, nil
 	}
 
-	if !askUserChoice(IORequestCh) {
-		return []string{}
+	if ok, errs := confirm(args, IORequestCh); !ok {
+		return nil, errs
 	}
 
 	d.Cleanup()
 
-	return []string{}
+	return nil, nil
 }
 
-func clear(d *structpkg.Dict, args []string, IORequestCh chan iopkg.IORequest) []string {
+func clear(d *synodict.Dictionary, args []string, IORequestCh chan iopkg.IORequest) (result, []error) {
 	if d.IsEmpty() {
-		return []string{"dictionary is already empty"}
+		return messageResult{Message: "dictionary is already empty"}, nil
 	}
 
-	if !askUserChoice(IORequestCh) {
-		return []string{}
+	if ok, errs := confirm(args, IORequestCh); !ok {
+		return nil, errs
 	}
 
 	d.Clear()
 
-	return []string{}
+	return nil, nil
+}
+
+func undo(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	n := 1
+
+	if len(args) > 0 {
+		n, _ = strconv.Atoi(args[0])
+	}
+
+	return changesResult{
+		Changes: append([]string{}, d.Undo(n)...),
+		done:    "undone",
+		none:    "nothing to undo",
+	}, nil
 }
 
-func importDict(d *structpkg.Dict, args []string, IORequestCh chan iopkg.IORequest) []string {
+func redo(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	n := 1
+
+	if len(args) > 0 {
+		n, _ = strconv.Atoi(args[0])
+	}
+
+	return changesResult{
+		Changes: append([]string{}, d.Redo(n)...),
+		done:    "redone",
+		none:    "nothing to redo",
+	}, nil
+}
+
+func history(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	if len(args) > 0 {
+		switch args[0] {
+		case "clear":
+			d.ClearHistory()
+			return messageResult{Message: "history cleared"}, nil
+
+		case "depth":
+			depth, _ := strconv.Atoi(args[1])
+			d.SetHistoryDepth(depth)
+
+			return messageResult{
+				Message: fmt.Sprintf("history depth set to %d", d.HistoryDepth()),
+				Value:   d.HistoryDepth(),
+			}, nil
+		}
+	}
+
+	labels, redoCount := d.History()
+
+	return historyResult{
+		Entries:  append([]string{}, labels...),
+		Depth:    d.HistoryDepth(),
+		Redoable: redoCount,
+	}, nil
+}
+
+func set(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	err := SetOutputMode(args[1])
+
+	if err != nil {
+		return nil, errorList(err)
+	}
+
+	return messageResult{Message: fmt.Sprintf("output mode set to %s", outputMode), Value: outputMode}, nil
+}
+
+func askImportSource(IORequestCh chan iopkg.IORequest) (string, string, bool) {
 	stages := [][]string{
 		{
 			"please choose the import format:",
-			"gob  - GOB",
-			"csv  - CSV",
-			"csvc - CSV condensed",
-			"c    - go back without import",
-			"done - stop execution",
+			"gob   - GOB",
+			"csv   - CSV",
+			"csvc  - CSV condensed",
+			"json  - JSON",
+			"jsonl - JSON Lines",
+			"c     - go back without import",
+			"done  - stop execution",
 		},
 		{
 			"please specify the file location:",
@@ -340,7 +537,7 @@ func importDict(d *structpkg.Dict, args []string, IORequestCh chan iopkg.IOReque
 				"choose one of listed below:",
 			)
 
-			regexes = append(regexes, `^(gob|csv|csvc|c)$`)
+			regexes = append(regexes, `^(gob|csv|csvc|json|jsonl|c)$`)
 
 		case 1:
 			errorPrompts = append(
@@ -364,7 +561,7 @@ func importDict(d *structpkg.Dict, args []string, IORequestCh chan iopkg.IOReque
 		response, ok := <-request.InCh
 
 		if !ok {
-			return []string{}
+			return "", "", false
 		}
 
 		if response == "c" {
@@ -379,12 +576,13 @@ func importDict(d *structpkg.Dict, args []string, IORequestCh chan iopkg.IOReque
 			continue
 
 		case 1:
-			path = response
+			path = unquote(response)
 
 			for {
-				_, err := stgpkg.Read(path)
+				file, err := stgpkg.Open(path)
 
 				if err == nil {
+					file.Close()
 					stage++
 					break
 				}
@@ -400,122 +598,199 @@ func importDict(d *structpkg.Dict, args []string, IORequestCh chan iopkg.IOReque
 				path, ok = <-request.InCh
 
 				if !ok {
-					return []string{}
+					return "", "", false
 				}
+
+				path = unquote(path)
 			}
 		}
 	}
 
-	if stage == -1 {
-		return []string{"import canceled"}
+	return format, path, stage != -1
+}
+
+func showPreview(pending *synodict.PendingImport, path string, IORequestCh chan iopkg.IORequest) {
+	IORequestCh <- iopkg.IORequest{
+		Out:     true,
+		In:      false,
+		Prompts: newPreviewResult(path, pending.Preview(false)).text(),
 	}
+}
 
-	if !d.IsEmpty() {
-		stages = [][]string{
-			{
-				"current dictionary is not empty. choose the action:",
-				"overwrite (o) - clear the current dictionary and replace it with the imported one",
-				"merge (m)     - merge the imported dictionary with the current one",
-				"cancel (c)    - go back without importing",
-				"done          - stop execution",
-			},
-			{
-				"do you want to save the dictionary before overwriting?",
-				"yes (y)    - export and save the current dictionary first",
-				"no (n)     - continue without saving",
-				"cancel (c) - go back to the previous step",
-				"done       - stop execution",
-			},
-		}
+// askImportConflict returns whether to go on with the import and whether
+// to overwrite the current dictionary instead of merging.
+func askImportConflict(d *synodict.Dictionary, IORequestCh chan iopkg.IORequest) (bool, bool) {
+	stages := [][]string{
+		{
+			"current dictionary is not empty. choose the action:",
+			"overwrite (o) - clear the current dictionary and replace it with the imported one",
+			"merge (m)     - merge the imported dictionary with the current one",
+			"cancel (c)    - go back without importing",
+			"done          - stop execution",
+		},
+		{
+			"do you want to save the dictionary before overwriting?",
+			"yes (y)    - export and save the current dictionary first",
+			"no (n)     - continue without saving",
+			"cancel (c) - go back to the previous step",
+			"done       - stop execution",
+		},
+	}
 
-		stage = 0
+	stage := 0
+	overwrite := false
 
-		for stage < len(stages) && stage >= 0 {
-			errorPrompts := []string{"choose one of listed below:"}
-			errorPrompts = append(errorPrompts, stages[stage][1:]...)
+	for stage < len(stages) && stage >= 0 {
+		errorPrompts := []string{"choose one of listed below:"}
+		errorPrompts = append(errorPrompts, stages[stage][1:]...)
 
-			regexes := []string{}
+		regexes := []string{}
 
-			switch stage {
-			case 0:
-				regexes = append(regexes, `^(o|m|c)$`)
+		switch stage {
+		case 0:
+			regexes = append(regexes, `^(o|m|c)$`)
 
-			case 1:
-				regexes = append(regexes, `^(y|n|c)$`)
-			}
+		case 1:
+			regexes = append(regexes, `^(y|n|c)$`)
+		}
 
-			request := iopkg.IORequest{
-				Out:                 true,
-				In:                  true,
-				Prompts:             stages[stage],
-				InCh:                make(chan string),
-				InValidationRegexes: regexes,
-				InErrorPrompts:      errorPrompts,
-			}
+		request := iopkg.IORequest{
+			Out:                 true,
+			In:                  true,
+			Prompts:             stages[stage],
+			InCh:                make(chan string),
+			InValidationRegexes: regexes,
+			InErrorPrompts:      errorPrompts,
+		}
 
-			IORequestCh <- request
-			response, ok := <-request.InCh
+		IORequestCh <- request
+		response, ok := <-request.InCh
 
-			if !ok {
-				return []string{}
+		if !ok {
+			return false, false
+		}
+
+		if response == "c" {
+			stage--
+			continue
+		}
+
+		switch stage {
+		case 0:
+			switch response {
+			case "o":
+				stage++
+
+			case "m":
+				stage = len(stages)
 			}
 
-			if response == "c" {
-				stage--
-				continue
+		case 1:
+			switch response {
+			case "y":
+				exportDict(d, []string{}, IORequestCh)
+				overwrite = true
+				stage++
+
+			case "n":
+				overwrite = true
+				stage++
 			}
+		}
+	}
 
-			switch stage {
-			case 0:
-				switch response {
-				case "o":
-					stage++
+	return stage != -1, overwrite
+}
 
-				case "m":
-					stage = len(stages)
-				}
+func importDict(d *synodict.Dictionary, args []string, IORequestCh chan iopkg.IORequest) (result, []error) {
+	params := positional(args)
+	format := ""
+	path := ""
 
-			case 1:
-				switch response {
-				case "y":
-					exportDict(d, args, IORequestCh)
-					d.Clear()
-					stage++
+	switch {
+	case len(params) == 2:
+		format, path = params[0], params[1]
 
-				case "n":
-					d.Clear()
-					stage++
-				}
-			}
-		}
+	case IORequestCh == nil:
+		return nil, []error{errors.New("import failed: format and path are required in non-interactive mode")}
+
+	default:
+		var ok bool
+		format, path, ok = askImportSource(IORequestCh)
 
-		if stage == -1 {
-			return []string{"import canceled"}
+		if !ok {
+			return messageResult{Message: "import canceled"}, nil
 		}
 	}
 
-	err := d.Import(path, format)
+	pending, err := d.ReadImportFile(path, synodict.Format(format))
 
 	if err != nil {
-		return []string{err.Error()}
+		return nil, errorList(err)
 	}
 
-	return []string{"imported successfully"}
+	if hasFlag(args, "--dry-run") {
+		return newPreviewResult(path, pending.Preview(hasFlag(args, "--overwrite"))), nil
+	}
+
+	overwrite := hasFlag(args, "--overwrite")
+
+	switch {
+	case d.IsEmpty():
+		if IORequestCh != nil && len(params) != 2 {
+			showPreview(pending, path, IORequestCh)
+
+			if !askUserChoice(IORequestCh) {
+				return messageResult{Message: "import canceled"}, nil
+			}
+		}
+
+	case overwrite, hasFlag(args, "--merge"):
+
+	case IORequestCh == nil:
+		return nil, []error{errors.New("import failed: current dictionary is not empty, use --merge or --overwrite")}
+
+	default:
+		showPreview(pending, path, IORequestCh)
+
+		var ok bool
+		ok, overwrite = askImportConflict(d, IORequestCh)
+
+		if !ok {
+			return messageResult{Message: "import canceled"}, nil
+		}
+	}
+
+	pending.Apply(overwrite)
+
+	return messageResult{Message: "imported successfully", Value: path}, nil
 }
 
-func exportDict(d *structpkg.Dict, args []string, IORequestCh chan iopkg.IORequest) []string {
-	if d.IsEmpty() {
-		return []string{"dictionary is empty"}
+var errFileExists = errors.New("already exists")
+
+func exportTo(d *synodict.Dictionary, format, path string, force bool) (string, error) {
+	if extension := synodict.Format(format).Extension(); !strings.HasSuffix(path, extension) {
+		path += extension
 	}
 
+	if _, err := os.Stat(path); err == nil && !force {
+		return path, fmt.Errorf("export failed: file %s %w, use --force to overwrite it", path, errFileExists)
+	}
+
+	return path, d.ExportFile(path, synodict.Format(format))
+}
+
+func askExportTarget(d *synodict.Dictionary, IORequestCh chan iopkg.IORequest) (string, bool) {
 	stages := [][]string{
 		{
 			"please choose the export format:",
-			"gob  - GOB",
-			"csv  - CSV",
-			"csvc - CSV condensed",
-			"c    - go back without export",
-			"done - stop execution",
+			"gob   - GOB",
+			"csv   - CSV",
+			"csvc  - CSV condensed",
+			"json  - JSON",
+			"jsonl - JSON Lines",
+			"c     - go back without export",
+			"done  - stop execution",
 		},
 		{
 			"please specify the desired file location:",
@@ -539,7 +814,7 @@ func exportDict(d *structpkg.Dict, args []string, IORequestCh chan iopkg.IOReque
 				"choose one of listed below:",
 			)
 
-			regexes = append(regexes, `^(gob|csv|csvc|c)$`)
+			regexes = append(regexes, `^(gob|csv|csvc|json|jsonl|c)$`)
 
 		case 1:
 			errorPrompts = append(
@@ -563,7 +838,7 @@ func exportDict(d *structpkg.Dict, args []string, IORequestCh chan iopkg.IOReque
 		response, ok := <-request.InCh
 
 		if !ok {
-			return []string{}
+			return "", false
 		}
 
 		if response == "c" {
@@ -578,24 +853,41 @@ func exportDict(d *structpkg.Dict, args []string, IORequestCh chan iopkg.IOReque
 			continue
 
 		case 1:
-			path = response
+			path = unquote(response)
 
-			for {
-				if !strings.HasSuffix(path, common.FormatFileExtensions[format]) {
-					path += common.FormatFileExtensions[format]
-				}
+			force := false
 
-				err := d.Export(path, format)
+			for {
+				var err error
+				path, err = exportTo(d, format, path, force)
 
 				if err == nil {
 					stage++
 					break
 				}
 
+				prompt := "ERROR ~ " + err.Error()
+
+				if errors.Is(err, errFileExists) {
+					IORequestCh <- iopkg.IORequest{
+						Out:     true,
+						In:      false,
+						Prompts: []string{"file " + path + " already exists, overwrite it?"},
+					}
+
+					if askUserChoice(IORequestCh) {
+						force = true
+						continue
+					}
+
+					prompt = "please specify another file location:"
+				}
+
+				force = false
 				request = iopkg.IORequest{
 					Out:     true,
 					In:      true,
-					Prompts: []string{"ERROR ~ " + err.Error()},
+					Prompts: []string{prompt},
 					InCh:    make(chan string),
 				}
 
@@ -603,66 +895,217 @@ func exportDict(d *structpkg.Dict, args []string, IORequestCh chan iopkg.IOReque
 				path, ok = <-request.InCh
 
 				if !ok {
-					return []string{}
+					return "", false
 				}
+
+				path = unquote(path)
 			}
 		}
 	}
 
-	if stage == -1 {
-		return []string{"export canceled"}
+	return path, stage != -1
+}
+
+func exportDict(d *synodict.Dictionary, args []string, IORequestCh chan iopkg.IORequest) (result, []error) {
+	if d.IsEmpty() {
+		return messageResult{Message: "dictionary is empty"}, nil
+	}
+
+	params := positional(args)
+	path := ""
+
+	switch {
+	case len(params) == 2:
+		var err error
+		path, err = exportTo(d, params[0], params[1], hasFlag(args, "--force"))
+
+		if err != nil {
+			return nil, []error{err}
+		}
+
+	case IORequestCh == nil:
+		return nil, []error{errors.New("export failed: format and path are required in non-interactive mode")}
+
+	default:
+		var ok bool
+		path, ok = askExportTarget(d, IORequestCh)
+
+		if !ok {
+			return messageResult{Message: "export canceled"}, nil
+		}
+	}
+
+	if workFile == nil {
+		markSaved(d.Changes())
+	}
+
+	return messageResult{Message: fmt.Sprintf("exported successfully to %s", path), Value: path}, nil
+}
+
+func save(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	if workFile == nil {
+		return nil, []error{errors.New("save failed: no working file, start with --db path")}
+	}
+
+	path, err := workFile.save()
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	return messageResult{Message: fmt.Sprintf("saved to %s", path), Value: path}, nil
+}
+
+func compact(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	if workFile == nil {
+		return nil, []error{errors.New("compact failed: no working file, start with --db path")}
+	}
+
+	path, err := workFile.save()
+
+	if err != nil {
+		return nil, []error{err}
+	}
+
+	return messageResult{Message: fmt.Sprintf("change log folded into %s", path), Value: path}, nil
+}
+
+func diff(d *synodict.Dictionary, args []string, _ chan iopkg.IORequest) (result, []error) {
+	params := positional(args)
+	name, explicit := flagValue(args, "--format")
+
+	// --format comes last, so its value is the last positional argument;
+	// it reads the files in that format instead of the one their
+	// extensions suggest
+	if explicit {
+		params = params[:len(params)-1]
+	}
+
+	load := func(path string) (*synodict.Dictionary, error) {
+		if explicit {
+			return synodict.Load(path, synodict.Format(name))
+		}
+
+		return synodict.Load(path, formatFromPath(path))
+	}
+
+	old, err := load(params[0])
+
+	if err != nil {
+		return nil, []error{fmt.Errorf("diff failed: %w", err)}
 	}
 
-	return []string{fmt.Sprintf("exported successfully to %s", path)}
+	current := d
+
+	if len(params) == 2 {
+		current, err = load(params[1])
+
+		if err != nil {
+			return nil, []error{fmt.Errorf("diff failed: %w", err)}
+		}
+	}
+
+	return newDiffResult(old.Diff(current)), nil
 }
 
-func help(d *structpkg.Dict, args []string, IORequestCh chan iopkg.IORequest) []string {
-	return []string{
+func help(d *synodict.Dictionary, args []string, IORequestCh chan iopkg.IORequest) (result, []error) {
+	return linesResult{
 		"available commands:",
+		"words may refer to a single sense as \"word#n\"; \"word\" alone links the first sense and queries all of them",
 		"add \"word1\"...               - adds each word to the dictionary if not already present, and links them as synonyms",
 		"add-words \"word1\"...         - adds each word to the dictionary if not already present (does not link them as synonyms)",
 		"remove \"word1\"...            - removes each word from the dictionary if already present",
 		"unlink \"word1\" \"word2\"       - removes synonym link between words (does not delete the words themselves)",
 		"unlink-clean \"word1\" \"word2\" - removes synonym link between words (deletes words if they have no other synonyms)",
+		"  --force                    - skips the confirmation prompt",
+		"add-antonym \"word1\" \"word2\"  - links the words as antonyms",
+		"unlink-antonym \"w1\" \"w2\"     - removes antonym link between words",
+		"add-broader \"word\" \"broader\" - records that the second word is a broader term (hypernym) of the first",
+		"unlink-broader \"w\" \"broader\" - removes broader term link between words",
+		"add-sense \"word\" [\"gloss\"]   - adds a new sense of the word (e.g. \"bank#2\") with an optional gloss",
+		"gloss \"word#n\" \"gloss\"       - sets the gloss of the sense (empty gloss removes it)",
+		"senses \"word\"                - prints all senses of the word with their glosses",
 		"check \"word1\" \"word2\"        - checks if the words are synonyms (directly or transitively)",
 		"check-direct \"word1\" \"word2\" - checks if the words are directly linked as synonyms",
+		"path \"word1\" \"word2\"         - prints the shortest chain of direct links between the words",
+		"  --all-shortest             - prints every equally short chain",
 		"exists \"word\"                - checks if the word exists in the dictionary",
+		"suggest \"word\"               - prints words spelled like the word, to find the one you meant",
 		"count \"word\"                 - prints the number of synonyms of the word",
 		"synonyms \"word\"              - prints all synonyms of the word",
 		"direct-synonyms \"word\"       - prints only directly linked synonyms (words that were explicitly connected)",
+		"antonyms \"word\"              - prints all antonyms of the word",
+		"broader \"word\"               - prints broader terms (hypernyms) of the word",
+		"narrower \"word\"              - prints narrower terms (hyponyms) of the word",
 		"count-groups                 - prints the number of synonym groups",
 		"groups                       - prints all synonym groups",
 		"count-words                  - prints the total number of words in the dictionary",
 		"words                        - prints all words",
-		"cleanup                      - removes words that have no synonyms from the dictionary",
-		"clear                        - clears the dictionary (warning: cannot be undone)",
-		"import                       - import dictionary (supports gob/csv); if current dictionary is not empty, you will be prompted to save, merge, or overwrite",
-		"export                       - export dictionary (supports gob/csv)",
+		"search pattern [--limit n] [--page n] - prints the words matching the pattern, 20 per page",
+		"  fast*, f?st, [a-f]*        - glob patterns match the whole word",
+		"  /^un.+able$/               - a regular expression between slashes matches anywhere in the word",
+		"cleanup [--force]            - removes words that have no synonyms from the dictionary",
+		"clear [--force]              - clears the dictionary",
+		"undo [n]                     - reverts the last n changes (1 by default)",
+		"redo [n]                     - re-applies the last n reverted changes (1 by default)",
+		"history                      - prints the list of changes that can be reverted",
+		"history clear                - forgets all recorded changes",
+		"history depth n              - sets how many changes are remembered (0 disables history)",
+		"import                       - import dictionary (supports gob/csv/json/jsonl); if current dictionary is not empty, you will be prompted to save, merge, or overwrite",
+		"export                       - export dictionary (supports gob/csv/json/jsonl)",
+		"import fmt \"path\" [--merge|--overwrite] - imports without prompts (fmt is gob, csv, csvc, json or jsonl)",
+		"  --dry-run                  - only prints what the import would change",
+		"export fmt \"path\" [--force]  - exports without prompts (--force overwrites an existing file)",
+		"diff \"file1\" [\"file2\"] [--format fmt] - compares two dictionary files, or a file with the current dictionary",
+		"  fmt                        - reads the files as gob, csv, csvc, json or jsonl instead of guessing from the extension",
+		"save                         - writes the dictionary to the working file given with --db",
+		"compact                      - folds the change log of the working file into a fresh snapshot",
+		"set output text|json|tsv     - switches the output format (json and tsv are meant for scripts)",
 		"help                         - prints this help message",
 		"done                         - stops execution",
-	}
+	}, nil
 }
 
 // map
-var cmdHandlers = map[string]func(d *structpkg.Dict, args []string, IORequestCh chan iopkg.IORequest) []string{
+var cmdHandlers = map[string]func(d *synodict.Dictionary, args []string, IORequestCh chan iopkg.IORequest) (result, []error){
 	"add":             add,
 	"add-words":       addWords,
 	"remove":          remove,
 	"unlink":          unlink,
 	"unlink-clean":    unlinkClean,
+	"add-antonym":     addAntonym,
+	"unlink-antonym":  unlinkAntonym,
+	"add-broader":     addBroader,
+	"unlink-broader":  unlinkBroader,
+	"add-sense":       addSense,
+	"gloss":           setGloss,
+	"senses":          senses,
 	"check":           check,
 	"check-direct":    checkDirect,
+	"path":            path,
 	"exists":          exists,
+	"suggest":         suggest,
 	"count":           count,
 	"synonyms":        synonyms,
 	"direct-synonyms": directSynonyms,
+	"antonyms":        antonyms,
+	"broader":         broader,
+	"narrower":        narrower,
 	"count-groups":    countGroups,
 	"groups":          groups,
 	"count-words":     countWords,
 	"words":           words,
+	"search":          search,
 	"cleanup":         cleanup,
 	"clear":           clear,
+	"undo":            undo,
+	"redo":            redo,
+	"history":         history,
 	"import":          importDict,
 	"export":          exportDict,
+	"diff":            diff,
+	"save":            save,
+	"compact":         compact,
+	"set":             set,
 	"help":            help,
 }
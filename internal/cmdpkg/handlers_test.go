@@ -0,0 +1,32 @@
+package cmdpkg
+
+import (
+	"bytes"
+	"strings"
+	"synodict-go/internal/corepkg"
+	"synodict-go/synodict"
+	"testing"
+)
+
+func TestSearchKeepsBackslashes(t *testing.T) {
+	core = corepkg.New()
+	dict = synodict.FromCore(core)
+
+	t.Cleanup(func() {
+		core = corepkg.New()
+		dict = synodict.FromCore(core)
+	})
+
+	var out, errOut bytes.Buffer
+	script := "add \"ab\" \"a-b\"\nsearch /^a\\Wb$/\n"
+
+	if code := RunBatch(strings.NewReader(script), &out, &errOut, false); code != ExitOK {
+		t.Fatalf("RunBatch() = %d, errors:\n%s", code, errOut.String())
+	}
+
+	want := "words matching \"/^a\\Wb$/\" (1 total, page 1 of 1):\n1) a-b\n"
+
+	if got := out.String(); !strings.HasSuffix(got, want) {
+		t.Errorf("search printed:\n%s\nwant:\n%s", got, want)
+	}
+}
@@ -0,0 +1,138 @@
+package cmdpkg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"synodict-go/internal/iopkg"
+	"synodict-go/synodict"
+)
+
+const (
+	OutputText = "text"
+	OutputJson = "json"
+	OutputTsv  = "tsv"
+)
+
+var outputMode = OutputText
+
+var tsvReplacer = strings.NewReplacer("\t", " ", "\r", " ", "\n", " ")
+
+var errIncorrectSyntax = errors.New("incorrect syntax")
+
+// errorObject adds the command syntax errors to the dictionary's codes.
+func errorObject(err error) synodict.ErrorInfo {
+	info := synodict.DescribeError(err)
+	var syntaxErr *iopkg.SyntaxError
+
+	if errors.Is(err, errIncorrectSyntax) {
+		info.Code = "syntax_error"
+	}
+
+	if errors.As(err, &syntaxErr) {
+		info.Column = syntaxErr.Column
+	}
+
+	return info
+}
+
+type envelope struct {
+	Command string               `json:"command"`
+	Line    int                  `json:"line,omitempty"`
+	OK      bool                 `json:"ok"`
+	Result  result               `json:"result,omitempty"`
+	Errors  []synodict.ErrorInfo `json:"errors,omitempty"`
+}
+
+func SetOutputMode(mode string) error {
+	switch mode {
+	case OutputText, OutputJson, OutputTsv:
+		outputMode = mode
+		return nil
+	}
+
+	return fmt.Errorf("unknown output mode %q (expected text, json or tsv)", mode)
+}
+
+func render(cmd string, lineNo int, res result, errs []error) ([]string, []string) {
+	switch outputMode {
+	case OutputJson:
+		return renderJson(cmd, lineNo, res, errs), nil
+
+	case OutputTsv:
+		return renderTsv(lineNo, res, errs)
+	}
+
+	return renderText(lineNo, res, errs)
+}
+
+func renderText(lineNo int, res result, errs []error) ([]string, []string) {
+	output := []string{}
+	errOutput := []string{}
+
+	if res != nil {
+		output = res.text()
+	}
+
+	for _, err := range errs {
+		if lineNo > 0 {
+			errOutput = append(errOutput, fmt.Sprintf("error: line %d: %s", lineNo, err))
+		} else {
+			errOutput = append(errOutput, "ERROR ~ "+err.Error())
+		}
+	}
+
+	return output, errOutput
+}
+
+func renderTsv(lineNo int, res result, errs []error) ([]string, []string) {
+	output := []string{}
+	errOutput := []string{}
+
+	if res != nil {
+		for _, row := range res.rows() {
+			fields := []string{}
+
+			for _, field := range row {
+				fields = append(fields, tsvReplacer.Replace(field))
+			}
+
+			output = append(output, strings.Join(fields, "\t"))
+		}
+	}
+
+	for _, err := range errs {
+		errOutput = append(errOutput, fmt.Sprintf("error\t%d\t%s\t%s", lineNo, errorObject(err).Code, tsvReplacer.Replace(err.Error())))
+	}
+
+	return output, errOutput
+}
+
+func renderJson(cmd string, lineNo int, res result, errs []error) []string {
+	response := envelope{
+		Line:   lineNo,
+		OK:     len(errs) == 0,
+		Result: res,
+	}
+
+	if fields := strings.Fields(cmd); len(fields) > 0 {
+		response.Command = fields[0]
+	}
+
+	for _, err := range errs {
+		response.Errors = append(response.Errors, errorObject(err))
+	}
+
+	data, err := json.Marshal(response)
+
+	if err != nil {
+		data, _ = json.Marshal(envelope{
+			Command: response.Command,
+			Line:    lineNo,
+			Errors:  []synodict.ErrorInfo{errorObject(err)},
+		})
+	}
+
+	return []string{string(data)}
+}
@@ -1,24 +1,59 @@
 package cmdpkg
 
-var cmdRegexes = []string{
-	`^add(?:\s+"[A-Za-zÀ-ɏЀ-ӿ\- ]+")+$`,
-	`^add-words(?:\s+"[A-Za-zÀ-ɏЀ-ӿ\- ]+")+$`,
-	`^remove(?:\s+"[A-Za-zÀ-ɏЀ-ӿ\- ]+")+$`,
-	`^unlink\s+"[A-Za-zÀ-ɏЀ-ӿ\- ]+"\s+"[A-Za-zÀ-ɏЀ-ӿ\- ]+"$`,
-	`^unlink-clean\s+"[A-Za-zÀ-ɏЀ-ӿ\- ]+"\s+"[A-Za-zÀ-ɏЀ-ӿ\- ]+"$`,
-	`^check\s+"[A-Za-zÀ-ɏЀ-ӿ\- ]+"\s+"[A-Za-zÀ-ɏЀ-ӿ\- ]+"$`,
-	`^check-direct\s+"[A-Za-zÀ-ɏЀ-ӿ\- ]+"\s+"[A-Za-zÀ-ɏЀ-ӿ\- ]+"$`,
-	`^exists\s+"[A-Za-zÀ-ɏЀ-ӿ\- ]+"$`,
-	`^count\s+"[A-Za-zÀ-ɏЀ-ӿ\- ]+"$`,
-	`^synonyms\s+"[A-Za-zÀ-ɏЀ-ӿ\- ]+"$`,
-	`^direct-synonyms\s+"[A-Za-zÀ-ɏЀ-ӿ\- ]+"$`,
-	`^count-groups$`,
-	`^groups$`,
-	`^count-words$`,
-	`^words$`,
-	`^cleanup$`,
-	`^clear$`,
-	`^import$`,
-	`^export$`,
-	`^help$`,
+import "synodict-go/internal/common"
+
+const glossPattern = `"(?:[^"\\]|\\.)*"`
+const pathPattern = `"(?:[^"\\]|\\.)+"`
+const searchPattern = `(?:"(?:[^"\\]|\\.)+"|(?:[^\s"\\]|\\.)+)`
+const formatPattern = `(?:gob|csv|csvc|json|jsonl)`
+
+var cmdRegexes = buildCmdRegexes(common.DefaultWordPolicy)
+
+// buildCmdRegexes accepts the words the dictionary's word policy allows, so
+// input is rejected before it reaches the dictionary.
+func buildCmdRegexes(policy common.WordPolicy) []string {
+	wordPattern := `"` + policy.Pattern() + `(?:#[1-9][0-9]*)?"`
+
+	return []string{
+		`^add(?:\s+` + wordPattern + `)+$`,
+		`^add-words(?:\s+` + wordPattern + `)+$`,
+		`^remove(?:\s+` + wordPattern + `)+$`,
+		`^unlink\s+` + wordPattern + `\s+` + wordPattern + `$`,
+		`^unlink-clean\s+` + wordPattern + `\s+` + wordPattern + `(?:\s+--force)?$`,
+		`^add-antonym\s+` + wordPattern + `\s+` + wordPattern + `$`,
+		`^unlink-antonym\s+` + wordPattern + `\s+` + wordPattern + `$`,
+		`^add-broader\s+` + wordPattern + `\s+` + wordPattern + `$`,
+		`^unlink-broader\s+` + wordPattern + `\s+` + wordPattern + `$`,
+		`^add-sense\s+` + wordPattern + `(?:\s+` + glossPattern + `)?$`,
+		`^gloss\s+` + wordPattern + `\s+` + glossPattern + `$`,
+		`^senses\s+` + wordPattern + `$`,
+		`^check\s+` + wordPattern + `\s+` + wordPattern + `$`,
+		`^check-direct\s+` + wordPattern + `\s+` + wordPattern + `$`,
+		`^path\s+` + wordPattern + `\s+` + wordPattern + `(?:\s+--all-shortest)?$`,
+		`^exists\s+` + wordPattern + `$`,
+		`^suggest\s+` + wordPattern + `$`,
+		`^count\s+` + wordPattern + `$`,
+		`^synonyms\s+` + wordPattern + `$`,
+		`^direct-synonyms\s+` + wordPattern + `$`,
+		`^antonyms\s+` + wordPattern + `$`,
+		`^broader\s+` + wordPattern + `$`,
+		`^narrower\s+` + wordPattern + `$`,
+		`^count-groups$`,
+		`^groups$`,
+		`^search\s+` + searchPattern + `(?:\s+--(?:limit|page)\s+[0-9]+)*$`,
+		`^count-words$`,
+		`^words$`,
+		`^cleanup(?:\s+--force)?$`,
+		`^clear(?:\s+--force)?$`,
+		`^undo(?:\s+[1-9][0-9]*)?$`,
+		`^redo(?:\s+[1-9][0-9]*)?$`,
+		`^history(?:\s+clear|\s+depth\s+[0-9]+)?$`,
+		`^import(?:\s+` + formatPattern + `\s+` + pathPattern + `(?:\s+--(?:merge|overwrite))?(?:\s+--dry-run)?)?$`,
+		`^export(?:\s+` + formatPattern + `\s+` + pathPattern + `(?:\s+--force)?)?$`,
+		`^diff\s+` + pathPattern + `(?:\s+` + pathPattern + `)?(?:\s+--format\s+` + formatPattern + `)?$`,
+		`^save$`,
+		`^compact$`,
+		`^set\s+output\s+(?:text|json|tsv)$`,
+		`^help$`,
+	}
 }
@@ -0,0 +1,617 @@
+package cmdpkg
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"synodict-go/synodict"
+)
+
+type result interface {
+	text() []string
+	rows() [][]string
+}
+
+type senseEntry struct {
+	Word  string `json:"word"`
+	Gloss string `json:"gloss,omitempty"`
+}
+
+func (e senseEntry) String() string {
+	if e.Gloss != "" {
+		return fmt.Sprintf("%s (%s)", e.Word, e.Gloss)
+	}
+
+	return e.Word
+}
+
+func numbered(items []string) []string {
+	response := []string{}
+
+	for i, item := range items {
+		response = append(response, fmt.Sprintf("%d) %s", i+1, item))
+	}
+
+	return response
+}
+
+func yesNo(value bool) string {
+	if value {
+		return "yes"
+	}
+
+	return "no"
+}
+
+type messageResult struct {
+	Message string `json:"message"`
+	Value   any    `json:"value,omitempty"`
+}
+
+func (r messageResult) text() []string {
+	return []string{r.Message}
+}
+
+func (r messageResult) rows() [][]string {
+	if r.Value != nil {
+		return [][]string{{fmt.Sprint(r.Value)}}
+	}
+
+	return [][]string{{r.Message}}
+}
+
+type linesResult []string
+
+func (r linesResult) text() []string {
+	return r
+}
+
+func (r linesResult) rows() [][]string {
+	rows := [][]string{}
+
+	for _, line := range r {
+		rows = append(rows, []string{line})
+	}
+
+	return rows
+}
+
+type flagResult struct {
+	Name      string          `json:"name"`
+	Value     bool            `json:"value"`
+	Pairs     [][2]senseEntry `json:"pairs,omitempty"`
+	showPairs bool
+}
+
+func (r flagResult) text() []string {
+	response := []string{fmt.Sprintf("%s: %s", r.Name, yesNo(r.Value))}
+
+	if r.showPairs {
+		for _, pair := range r.Pairs {
+			response = append(response, fmt.Sprintf("%s ~ %s", pair[0], pair[1]))
+		}
+	}
+
+	return response
+}
+
+func (r flagResult) rows() [][]string {
+	rows := [][]string{{strconv.FormatBool(r.Value)}}
+
+	for _, pair := range r.Pairs {
+		rows = append(rows, []string{pair[0].Word, pair[1].Word})
+	}
+
+	return rows
+}
+
+type countResult struct {
+	Word  string `json:"word,omitempty"`
+	Count int    `json:"count"`
+	unit  string
+}
+
+func (r countResult) text() []string {
+	if r.Word == "" {
+		switch r.Count {
+		case 0:
+			return []string{fmt.Sprintf("dictionary has no %ss yet", r.unit)}
+
+		case 1:
+			return []string{fmt.Sprintf("dictionary contains 1 %s", r.unit)}
+
+		default:
+			return []string{fmt.Sprintf("dictionary contains %d %ss", r.Count, r.unit)}
+		}
+	}
+
+	switch r.Count {
+	case 0:
+		return []string{fmt.Sprintf("word \"%s\" has no %ss yet", r.Word, r.unit)}
+
+	case 1:
+		return []string{fmt.Sprintf("word \"%s\" has 1 %s", r.Word, r.unit)}
+
+	default:
+		return []string{fmt.Sprintf("word \"%s\" has %d %ss", r.Word, r.Count, r.unit)}
+	}
+}
+
+func (r countResult) rows() [][]string {
+	return [][]string{{strconv.Itoa(r.Count)}}
+}
+
+type listResult struct {
+	Word  string   `json:"word,omitempty"`
+	Items []string `json:"items"`
+	title string
+	empty string
+}
+
+func (r listResult) text() []string {
+	if len(r.Items) == 0 {
+		return []string{r.empty}
+	}
+
+	if r.title == "" {
+		return numbered(r.Items)
+	}
+
+	return append([]string{r.title}, numbered(r.Items)...)
+}
+
+func (r listResult) rows() [][]string {
+	return linesResult(r.Items).rows()
+}
+
+type searchResult struct {
+	Query string   `json:"query"`
+	Words []string `json:"words"`
+	Total int      `json:"total"`
+	Page  int      `json:"page"`
+	Pages int      `json:"pages"`
+	first int
+}
+
+func (r searchResult) text() []string {
+	if r.Total == 0 {
+		return []string{fmt.Sprintf("no words match \"%s\"", r.Query)}
+	}
+
+	if len(r.Words) == 0 {
+		if r.Pages == 1 {
+			return []string{fmt.Sprintf("words matching \"%s\" fill only 1 page", r.Query)}
+		}
+
+		return []string{fmt.Sprintf("words matching \"%s\" fill only %d pages", r.Query, r.Pages)}
+	}
+
+	response := []string{
+		fmt.Sprintf("words matching \"%s\" (%d total, page %d of %d):", r.Query, r.Total, r.Page, r.Pages),
+	}
+
+	for i, word := range r.Words {
+		response = append(response, fmt.Sprintf("%d) %s", r.first+i, word))
+	}
+
+	return response
+}
+
+func (r searchResult) rows() [][]string {
+	return linesResult(r.Words).rows()
+}
+
+type senseListResult struct {
+	Word   string       `json:"word"`
+	Senses []senseEntry `json:"senses"`
+}
+
+func (r senseListResult) text() []string {
+	if len(r.Senses) == 0 {
+		return []string{fmt.Sprintf("word \"%s\" has no senses yet", r.Word)}
+	}
+
+	items := []string{}
+
+	for _, sense := range r.Senses {
+		items = append(items, sense.String())
+	}
+
+	return append([]string{fmt.Sprintf("word \"%s\" sense list:", r.Word)}, numbered(items)...)
+}
+
+func (r senseListResult) rows() [][]string {
+	rows := [][]string{}
+
+	for _, sense := range r.Senses {
+		rows = append(rows, []string{sense.Word, sense.Gloss})
+	}
+
+	return rows
+}
+
+type senseSynonyms struct {
+	Sense    senseEntry `json:"sense"`
+	Synonyms []string   `json:"synonyms"`
+}
+
+type synonymsResult struct {
+	Word   string          `json:"word"`
+	Senses []senseSynonyms `json:"senses"`
+}
+
+func (r synonymsResult) text() []string {
+	response := []string{}
+
+	for i, sense := range r.Senses {
+		label := fmt.Sprintf("word \"%s\"", r.Word)
+
+		if len(r.Senses) > 1 {
+			label = fmt.Sprintf("sense \"%s\"", sense.Sense)
+		}
+
+		if len(sense.Synonyms) > 0 {
+			response = append(response, fmt.Sprintf("%s synonym list:", label))
+			response = append(response, numbered(sense.Synonyms)...)
+		} else {
+			response = append(response, fmt.Sprintf("%s has no synonyms yet", label))
+		}
+
+		if i < len(r.Senses)-1 {
+			response = append(response, "")
+		}
+	}
+
+	return response
+}
+
+func (r synonymsResult) rows() [][]string {
+	rows := [][]string{}
+
+	for _, sense := range r.Senses {
+		for _, synonym := range sense.Synonyms {
+			rows = append(rows, []string{sense.Sense.Word, synonym})
+		}
+	}
+
+	return rows
+}
+
+type pathResult struct {
+	From  string     `json:"from"`
+	To    string     `json:"to"`
+	Paths [][]string `json:"paths"`
+	all   bool
+}
+
+func (r pathResult) text() []string {
+	if len(r.Paths) == 0 {
+		return []string{fmt.Sprintf("words \"%s\" and \"%s\" are not synonyms", r.From, r.To)}
+	}
+
+	if !r.all {
+		return []string{"path: " + strings.Join(r.Paths[0], " → ")}
+	}
+
+	paths := []string{}
+
+	for _, p := range r.Paths {
+		paths = append(paths, strings.Join(p, " → "))
+	}
+
+	response := append(
+		[]string{fmt.Sprintf("shortest paths between \"%s\" and \"%s\":", r.From, r.To)},
+		numbered(paths)...,
+	)
+
+	if len(r.Paths) == synodict.MaxPaths {
+		response = append(response, fmt.Sprintf("only the first %d paths are listed", synodict.MaxPaths))
+	}
+
+	return response
+}
+
+func (r pathResult) rows() [][]string {
+	return r.Paths
+}
+
+type groupsResult struct {
+	Groups [][]senseEntry `json:"groups"`
+}
+
+func (r groupsResult) text() []string {
+	if len(r.Groups) == 0 {
+		return []string{"dictionary has no groups yet"}
+	}
+
+	response := []string{}
+
+	for i, group := range r.Groups {
+		response = append(response, fmt.Sprintf("%d group", i+1))
+
+		for j, word := range group {
+			response = append(response, fmt.Sprintf("%d) %s", j+1, word))
+		}
+
+		if i < len(r.Groups)-1 {
+			response = append(response, "")
+		}
+	}
+
+	return response
+}
+
+func (r groupsResult) rows() [][]string {
+	rows := [][]string{}
+
+	for i, group := range r.Groups {
+		for _, word := range group {
+			rows = append(rows, []string{strconv.Itoa(i + 1), word.Word, word.Gloss})
+		}
+	}
+
+	return rows
+}
+
+type changesResult struct {
+	Changes []string `json:"changes"`
+	done    string
+	none    string
+}
+
+func (r changesResult) text() []string {
+	if len(r.Changes) == 0 {
+		return []string{r.none}
+	}
+
+	response := []string{}
+
+	for _, label := range r.Changes {
+		response = append(response, r.done+": "+label)
+	}
+
+	return response
+}
+
+func (r changesResult) rows() [][]string {
+	return linesResult(r.Changes).rows()
+}
+
+type historyResult struct {
+	Entries  []string `json:"entries"`
+	Depth    int      `json:"depth"`
+	Redoable int      `json:"redoable"`
+}
+
+func (r historyResult) text() []string {
+	response := listResult{
+		Items: r.Entries,
+		title: fmt.Sprintf("history (newest first, depth %d):", r.Depth),
+		empty: "history is empty",
+	}.text()
+
+	if r.Redoable == 1 {
+		response = append(response, "1 action can be redone")
+	} else if r.Redoable > 1 {
+		response = append(response, fmt.Sprintf("%d actions can be redone", r.Redoable))
+	}
+
+	return response
+}
+
+func (r historyResult) rows() [][]string {
+	return linesResult(r.Entries).rows()
+}
+
+const previewSampleSize = 10
+
+func sample(items []string) string {
+	if len(items) <= previewSampleSize {
+		return strings.Join(items, ", ")
+	}
+
+	return strings.Join(items[:previewSampleSize], ", ") + fmt.Sprintf(" and %d more", len(items)-previewSampleSize)
+}
+
+type previewResult struct {
+	Source         string   `json:"source"`
+	WordsAdded     []string `json:"words_added"`
+	WordsRemoved   int      `json:"words_removed"`
+	EdgesAdded     int      `json:"edges_added"`
+	RelationsAdded int      `json:"relations_added"`
+	GroupsMerged   int      `json:"groups_merged"`
+	MergedInto     int      `json:"merged_into"`
+	LargestGroup   []string `json:"largest_group"`
+}
+
+func newPreviewResult(source string, preview synodict.ImportPreview) previewResult {
+	return previewResult{
+		Source:         source,
+		WordsAdded:     preview.WordsAdded,
+		WordsRemoved:   preview.WordsRemoved,
+		EdgesAdded:     preview.EdgesAdded,
+		RelationsAdded: preview.RelationsAdded,
+		GroupsMerged:   preview.GroupsMerged,
+		MergedInto:     preview.MergedInto,
+		LargestGroup:   preview.LargestGroup,
+	}
+}
+
+func (r previewResult) text() []string {
+	response := []string{fmt.Sprintf("importing %s would change:", r.Source)}
+
+	if len(r.WordsAdded) == 0 {
+		response = append(response, "words added: 0")
+	} else {
+		response = append(response, fmt.Sprintf("words added: %d (%s)", len(r.WordsAdded), sample(r.WordsAdded)))
+	}
+
+	if r.WordsRemoved > 0 {
+		response = append(response, fmt.Sprintf("words removed: %d", r.WordsRemoved))
+	}
+
+	response = append(response,
+		fmt.Sprintf("synonym links added: %d", r.EdgesAdded),
+		fmt.Sprintf("other relations added: %d", r.RelationsAdded),
+		fmt.Sprintf("existing groups merged: %d into %d", r.GroupsMerged, r.MergedInto),
+	)
+
+	if len(r.LargestGroup) > 0 {
+		response = append(response, fmt.Sprintf("largest new group: %d words (%s)", len(r.LargestGroup), sample(r.LargestGroup)))
+	}
+
+	return response
+}
+
+func (r previewResult) rows() [][]string {
+	return [][]string{
+		{"words_added", strconv.Itoa(len(r.WordsAdded))},
+		{"words_removed", strconv.Itoa(r.WordsRemoved)},
+		{"edges_added", strconv.Itoa(r.EdgesAdded)},
+		{"relations_added", strconv.Itoa(r.RelationsAdded)},
+		{"groups_merged", strconv.Itoa(r.GroupsMerged)},
+		{"merged_into", strconv.Itoa(r.MergedInto)},
+		{"largest_group", strconv.Itoa(len(r.LargestGroup))},
+	}
+}
+
+type linkEntry struct {
+	A    string `json:"a"`
+	B    string `json:"b"`
+	Type string `json:"type"`
+}
+
+func (e linkEntry) String() string {
+	switch e.Type {
+	case "synonym":
+		return fmt.Sprintf("link \"%s\" ~ \"%s\"", e.A, e.B)
+
+	case "hypernym":
+		return fmt.Sprintf("broader \"%s\" → \"%s\"", e.A, e.B)
+	}
+
+	return fmt.Sprintf("%s \"%s\" ~ \"%s\"", e.Type, e.A, e.B)
+}
+
+type groupChangeEntry struct {
+	From [][]string `json:"from"`
+	To   [][]string `json:"to"`
+}
+
+func joinGroups(groups [][]string, sep string) string {
+	parts := []string{}
+
+	for _, group := range groups {
+		parts = append(parts, "{"+strings.Join(group, ", ")+"}")
+	}
+
+	return strings.Join(parts, sep)
+}
+
+func (e groupChangeEntry) String() string {
+	return joinGroups(e.From, " + ") + " → " + joinGroups(e.To, " + ")
+}
+
+type diffResult struct {
+	WordsAdded   []string           `json:"words_added"`
+	WordsRemoved []string           `json:"words_removed"`
+	LinksAdded   []linkEntry        `json:"links_added"`
+	LinksRemoved []linkEntry        `json:"links_removed"`
+	GroupsMerged []groupChangeEntry `json:"groups_merged"`
+	GroupsSplit  []groupChangeEntry `json:"groups_split"`
+}
+
+func linkEntries(links []synodict.Link) []linkEntry {
+	entries := []linkEntry{}
+
+	for _, link := range links {
+		entries = append(entries, linkEntry{A: link.A, B: link.B, Type: link.Type.String()})
+	}
+
+	return entries
+}
+
+func groupChangeEntries(changes []synodict.GroupChange) []groupChangeEntry {
+	entries := []groupChangeEntry{}
+
+	for _, change := range changes {
+		entries = append(entries, groupChangeEntry{From: change.From, To: change.To})
+	}
+
+	return entries
+}
+
+func newDiffResult(diff synodict.Diff) diffResult {
+	return diffResult{
+		WordsAdded:   diff.WordsAdded,
+		WordsRemoved: diff.WordsRemoved,
+		LinksAdded:   linkEntries(diff.LinksAdded),
+		LinksRemoved: linkEntries(diff.LinksRemoved),
+		GroupsMerged: groupChangeEntries(diff.GroupsMerged),
+		GroupsSplit:  groupChangeEntries(diff.GroupsSplit),
+	}
+}
+
+func (r diffResult) text() []string {
+	response := []string{}
+
+	for _, word := range r.WordsAdded {
+		response = append(response, fmt.Sprintf("+ word \"%s\"", word))
+	}
+
+	for _, word := range r.WordsRemoved {
+		response = append(response, fmt.Sprintf("- word \"%s\"", word))
+	}
+
+	for _, link := range r.LinksAdded {
+		response = append(response, "+ "+link.String())
+	}
+
+	for _, link := range r.LinksRemoved {
+		response = append(response, "- "+link.String())
+	}
+
+	for _, change := range r.GroupsMerged {
+		response = append(response, "merged: "+change.String())
+	}
+
+	for _, change := range r.GroupsSplit {
+		response = append(response, "split: "+change.String())
+	}
+
+	if len(response) == 0 {
+		return []string{"no differences"}
+	}
+
+	return response
+}
+
+func (r diffResult) rows() [][]string {
+	rows := [][]string{}
+
+	for _, word := range r.WordsAdded {
+		rows = append(rows, []string{"+", "word", word})
+	}
+
+	for _, word := range r.WordsRemoved {
+		rows = append(rows, []string{"-", "word", word})
+	}
+
+	for _, link := range r.LinksAdded {
+		rows = append(rows, []string{"+", link.Type, link.A, link.B})
+	}
+
+	for _, link := range r.LinksRemoved {
+		rows = append(rows, []string{"-", link.Type, link.A, link.B})
+	}
+
+	for _, change := range r.GroupsMerged {
+		rows = append(rows, []string{"merged", joinGroups(change.From, " "), joinGroups(change.To, " ")})
+	}
+
+	for _, change := range r.GroupsSplit {
+		rows = append(rows, []string{"split", joinGroups(change.From, " "), joinGroups(change.To, " ")})
+	}
+
+	return rows
+}
@@ -0,0 +1,284 @@
+package cmdpkg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"synodict-go/internal/common"
+	"synodict-go/internal/stgpkg"
+	"synodict-go/synodict"
+	"time"
+)
+
+const DefaultAutosaveEvery = 1000
+const DefaultAutosaveInterval = 30 * time.Second
+
+// workingFile is the dictionary file given with --db. Every change is
+// appended to a log next to it (path + ".wal") as it happens; the log is
+// replayed over the file on startup and folded into a fresh snapshot
+// after every autosaveEvery changes, on "save" or "compact", and whenever
+// a change cannot be logged (imports, clear).
+type workingFile struct {
+	path          string
+	format        synodict.Format
+	autosaveEvery int
+	mu            sync.Mutex
+
+	wal      *stgpkg.WAL
+	snapshot uint64
+	stale    bool
+	logErr   error
+	logMu    sync.Mutex
+}
+
+var workFile *workingFile
+
+var savedChanges uint64
+
+var savedMu sync.Mutex
+
+func formatFromPath(path string) synodict.Format {
+	if format, ok := synodict.FormatFromPath(path); ok {
+		return format
+	}
+
+	return synodict.FormatGob
+}
+
+func markSaved(changes uint64) {
+	savedMu.Lock()
+	defer savedMu.Unlock()
+
+	savedChanges = changes
+}
+
+func unsavedChanges() uint64 {
+	savedMu.Lock()
+	defer savedMu.Unlock()
+
+	return dict.Changes() - savedChanges
+}
+
+// OpenWorkingFile loads the working file and replays its change log. The
+// returned warning is non-empty if a damaged log tail had to be dropped.
+func OpenWorkingFile(path string, autosaveEvery int) (string, error) {
+	file := &workingFile{
+		path:          path,
+		format:        formatFromPath(path),
+		autosaveEvery: autosaveEvery,
+	}
+
+	_, err := os.Stat(path)
+
+	switch {
+	case err == nil:
+		err = dict.ImportFile(path, file.format)
+
+		if err != nil {
+			return "", fmt.Errorf("loading %s failed: %w", path, err)
+		}
+
+	case !errors.Is(err, os.ErrNotExist):
+		return "", fmt.Errorf("loading %s failed: %w", path, err)
+	}
+
+	snapshot, err := stgpkg.FileSum(path)
+
+	if err != nil {
+		return "", fmt.Errorf("loading %s failed: %w", path, err)
+	}
+
+	wal, records, err := stgpkg.OpenWAL(path+".wal", snapshot)
+
+	if err != nil {
+		return "", fmt.Errorf("loading %s failed: %w", path, err)
+	}
+
+	err = core.Dict().Replay(records)
+
+	if err != nil {
+		wal.Close()
+		return "", fmt.Errorf("loading %s failed: %w", path, err)
+	}
+
+	dict.ClearHistory()
+	core.Dict().SetChangeLog(file)
+
+	file.wal = wal
+	file.snapshot = dict.Changes()
+	workFile = file
+	markSaved(file.snapshot)
+
+	if n := wal.Truncated(); n > 0 {
+		return fmt.Sprintf("dropped %d damaged bytes at the end of the change log %s.wal", n, path), nil
+	}
+
+	return "", nil
+}
+
+func (f *workingFile) Append(records [][]byte) {
+	f.logMu.Lock()
+	defer f.logMu.Unlock()
+
+	if f.stale {
+		return
+	}
+
+	if err := f.wal.Append(records); err != nil {
+		f.stale = true
+		f.logErr = err
+	}
+}
+
+func (f *workingFile) Invalidate() {
+	f.logMu.Lock()
+	defer f.logMu.Unlock()
+
+	f.stale = true
+}
+
+func (f *workingFile) isStale() bool {
+	f.logMu.Lock()
+	defer f.logMu.Unlock()
+
+	return f.stale
+}
+
+// discardLog drops the logged changes a new snapshot contains; tests
+// replace it to fail between the two steps.
+var discardLog = (*stgpkg.WAL).Discard
+
+// save writes a snapshot and drops the logged changes it contains. The
+// snapshot holds every change up to the point marked while it is encoded,
+// logged or not. A checkpoint in the log names it before it replaces the
+// previous one, so if the log cannot be cut back afterwards, the records
+// it already contains are skipped on the next start instead of being
+// replayed over it.
+func (f *workingFile) save() (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	changes := dict.Changes()
+	offset := int64(0)
+	wasStale := false
+
+	mark := func() {
+		f.logMu.Lock()
+		defer f.logMu.Unlock()
+
+		offset, wasStale = f.wal.Size(), f.stale
+		f.stale = false
+	}
+
+	checkpoint := func(sum []byte) error {
+		f.logMu.Lock()
+		defer f.logMu.Unlock()
+
+		return f.wal.Checkpoint(sum, offset)
+	}
+
+	err := core.Dict().ExportSnapshot(f.path, string(f.format), mark, checkpoint)
+
+	f.logMu.Lock()
+	defer f.logMu.Unlock()
+
+	if err != nil {
+		f.stale = f.stale || wasStale
+		return f.path, fmt.Errorf("save failed: %w", err)
+	}
+
+	f.snapshot = changes
+	markSaved(changes)
+
+	err = discardLog(f.wal, offset)
+
+	if err != nil {
+		return f.path, fmt.Errorf("save failed: %w", err)
+	}
+
+	return f.path, nil
+}
+
+// Save writes the working file if there is one and it has changes that
+// are not in its log.
+func Save() error {
+	if workFile == nil || !workFile.isStale() {
+		return nil
+	}
+
+	_, err := workFile.save()
+
+	return err
+}
+
+func autosave() error {
+	if workFile == nil {
+		return nil
+	}
+
+	changes := dict.Changes()
+
+	workFile.logMu.Lock()
+	stale, logErr, snapshot := workFile.stale, workFile.logErr, workFile.snapshot
+	workFile.logErr = nil
+	workFile.logMu.Unlock()
+
+	if !stale {
+		markSaved(changes)
+	}
+
+	every := uint64(workFile.autosaveEvery)
+
+	if stale || (every > 0 && changes-snapshot >= every) {
+		_, err := workFile.save()
+		return errors.Join(logErr, err)
+	}
+
+	return logErr
+}
+
+// AutosaveEvery saves the working file every interval until stop is closed,
+// if it has changes missing from its log or the log has grown past the
+// autosave limit.
+func AutosaveEvery(interval time.Duration, stop <-chan common.Void, report func(err error)) {
+	if workFile == nil || interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			if err := autosave(); err != nil {
+				report(err)
+			}
+
+		case <-stop:
+			return
+		}
+	}
+}
+
+// UnsavedWarning describes the changes that would be lost on exit, or
+// returns "" if there are none.
+func UnsavedWarning() string {
+	n := unsavedChanges()
+	changes := fmt.Sprintf("%d unsaved changes are", n)
+
+	switch {
+	case n == 0:
+		return ""
+
+	case n == 1:
+		changes = "1 unsaved change is"
+	}
+
+	if workFile != nil {
+		return fmt.Sprintf("%s lost, writing %s failed", changes, workFile.path)
+	}
+
+	return fmt.Sprintf("%s lost, export the dictionary or start with --db to keep them", changes)
+}
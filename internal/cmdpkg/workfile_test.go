@@ -0,0 +1,74 @@
+package cmdpkg
+
+import (
+	"errors"
+	"path/filepath"
+	"slices"
+	"synodict-go/internal/corepkg"
+	"synodict-go/internal/stgpkg"
+	"synodict-go/synodict"
+	"testing"
+)
+
+// openFresh opens the working file into a new dictionary, as a restart
+// would.
+func openFresh(t *testing.T, path string) {
+	t.Helper()
+
+	if workFile != nil {
+		workFile.wal.Close()
+	}
+
+	core = corepkg.New()
+	dict = synodict.FromCore(core)
+	workFile = nil
+
+	if _, err := OpenWorkingFile(path, DefaultAutosaveEvery); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestFailedCompactionKeepsUnloggedChanges(t *testing.T) {
+	t.Cleanup(func() {
+		discardLog = (*stgpkg.WAL).Discard
+		workFile.wal.Close()
+		workFile = nil
+		core = corepkg.New()
+		dict = synodict.FromCore(core)
+	})
+
+	path := filepath.Join(t.TempDir(), "work.json")
+	openFresh(t, path)
+
+	if err := dict.AddSynonyms("fast", "quick"); err != nil {
+		t.Fatal(err)
+	}
+
+	// logged, then undone by a change that cannot be logged
+	dict.Clear()
+
+	if err := dict.AddSynonyms("big", "large"); err != nil {
+		t.Fatal(err)
+	}
+
+	discardLog = func(*stgpkg.WAL, int64) error { return errors.New("disk full") }
+
+	if err := Save(); err == nil {
+		t.Fatal("Save succeeded although the log could not be cut back")
+	}
+
+	discardLog = (*stgpkg.WAL).Discard
+
+	// a change logged after the snapshot must still be replayed
+	if err := dict.AddSynonyms("old", "older"); err != nil {
+		t.Fatal(err)
+	}
+
+	openFresh(t, path)
+
+	want := []string{"big", "large", "old", "older"}
+
+	if got := dict.Words(); !slices.Equal(got, want) {
+		t.Errorf("after restart the words are %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,116 @@
+package common
+
+import (
+	"bytes"
+	"slices"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"golang.org/x/text/collate"
+	"golang.org/x/text/language"
+)
+
+// Words are ordered by the Unicode collation rules of a language (the root
+// order unless SetCollation picks another one): first by letter, ignoring
+// accents and case, then by accents, then by case. Runs of digits compare
+// by value. Words the rules consider equal are ordered by their bytes, so
+// the order is always total.
+
+// collators pools the collators of the current language: a collator keeps
+// state between calls and cannot be shared by goroutines.
+var collators atomic.Pointer[sync.Pool]
+
+func init() {
+	SetCollation(language.Und)
+}
+
+// SetCollation orders words by the collation rules of the language from
+// now on.
+func SetCollation(tag language.Tag) {
+	collators.Store(&sync.Pool{
+		New: func() any { return collate.New(tag, collate.Numeric) },
+	})
+}
+
+func withCollator(f func(c *collate.Collator)) {
+	pool := collators.Load()
+	c := pool.Get().(*collate.Collator)
+	defer pool.Put(c)
+
+	f(c)
+}
+
+func CompareWords(a, b string) int {
+	var result int
+
+	withCollator(func(c *collate.Collator) {
+		result = c.CompareString(a, b)
+	})
+
+	if result != 0 {
+		return result
+	}
+
+	return strings.Compare(a, b)
+}
+
+type collationKey struct {
+	key []byte
+	raw string
+}
+
+// SortWords sorts words in dictionary order, computing every collation key
+// only once.
+func SortWords(words []string) {
+	keys := make([]collationKey, len(words))
+
+	withCollator(func(c *collate.Collator) {
+		var buf collate.Buffer
+
+		for i, word := range words {
+			keys[i] = collationKey{key: c.KeyFromString(&buf, word), raw: word}
+		}
+	})
+
+	slices.SortFunc(keys, func(a, b collationKey) int {
+		if c := bytes.Compare(a.key, b.key); c != 0 {
+			return c
+		}
+
+		return strings.Compare(a.raw, b.raw)
+	})
+
+	for i, key := range keys {
+		words[i] = key.raw
+	}
+}
+
+func SortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+
+	for key := range m {
+		keys = append(keys, key)
+	}
+
+	SortWords(keys)
+
+	return keys
+}
+
+// SortGroups sorts every group and then the groups by their words.
+func SortGroups(groups [][]string) {
+	for _, group := range groups {
+		SortWords(group)
+	}
+
+	slices.SortFunc(groups, func(a, b []string) int {
+		for i := 0; i < len(a) && i < len(b); i++ {
+			if c := CompareWords(a[i], b[i]); c != 0 {
+				return c
+			}
+		}
+
+		return len(a) - len(b)
+	})
+}
@@ -0,0 +1,45 @@
+package common
+
+import (
+	"fmt"
+	"testing"
+
+	"golang.org/x/text/language"
+)
+
+func TestSortWords(t *testing.T) {
+	tests := []struct {
+		name  string
+		tag   language.Tag
+		words []string
+		want  string
+	}{
+		{"accents after letters", language.Und, []string{"ef", "éa", "eb"}, "[éa eb ef]"},
+		{"accents break ties", language.Und, []string{"résumé", "resume"}, "[resume résumé]"},
+		{"lower case first", language.Und, []string{"Apple", "apple"}, "[apple Apple]"},
+		{"digits by value", language.Und, []string{"bank#10", "bank#2", "bank"}, "[bank bank#2 bank#10]"},
+		{"latin before cyrillic", language.Und, []string{"дом", "house", "елка", "ёж"}, "[house дом ёж елка]"},
+		{"ties by bytes", language.Und, []string{"\u00e9", "e\u0301"}, "[e\u0301 \u00e9]"},
+		{"swedish ä after z", language.Swedish, []string{"ära", "zoo", "apa"}, "[apa zoo ära]"},
+		{"german ä with a", language.German, []string{"ära", "zoo", "apa"}, "[apa ära zoo]"},
+	}
+
+	t.Cleanup(func() { SetCollation(language.Und) })
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			SetCollation(tt.tag)
+			SortWords(tt.words)
+
+			if got := fmt.Sprint(tt.words); got != tt.want {
+				t.Errorf("SortWords() = %s, want %s", got, tt.want)
+			}
+
+			for i := 1; i < len(tt.words); i++ {
+				if CompareWords(tt.words[i-1], tt.words[i]) >= 0 {
+					t.Errorf("CompareWords(%q, %q) >= 0", tt.words[i-1], tt.words[i])
+				}
+			}
+		})
+	}
+}
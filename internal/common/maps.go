@@ -1,7 +1,9 @@
 package common
 
 var FormatFileExtensions = map[string]string{
-	"gob":  ".gob",
-	"csv":  ".csv",
-	"csvc": ".csv",
+	"gob":   ".gob",
+	"csv":   ".csv",
+	"csvc":  ".csv",
+	"json":  ".json",
+	"jsonl": ".jsonl",
 }
@@ -0,0 +1,162 @@
+package common
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// WordPolicy decides which words a dictionary accepts. A word is made of
+// letters of the allowed scripts (every script when Scripts is empty), the
+// marks that combine with them, spaces and hyphens, plus apostrophes,
+// periods and digits when enabled, and must contain at least one letter.
+// MaxLength counts characters; 0 means no limit.
+type WordPolicy struct {
+	Scripts     []string
+	Apostrophes bool
+	Periods     bool
+	Digits      bool
+	MaxLength   int
+}
+
+var DefaultWordPolicy = WordPolicy{Apostrophes: true}
+
+// the regexp package cannot repeat a pattern more often than this
+const maxPatternRepeat = 1000
+
+var apostrophes = []rune{'\'', '’'}
+
+func lookupScript(name string) (string, bool) {
+	if _, ok := unicode.Scripts[name]; ok {
+		return name, true
+	}
+
+	for script := range unicode.Scripts {
+		if strings.EqualFold(script, name) {
+			return script, true
+		}
+	}
+
+	return "", false
+}
+
+// Check reports settings that cannot be applied, such as unknown scripts.
+func (p WordPolicy) Check() error {
+	for _, name := range p.Scripts {
+		if _, ok := lookupScript(name); !ok {
+			return fmt.Errorf("unknown script %q", name)
+		}
+	}
+
+	if p.MaxLength < 0 {
+		return errors.New("maximum word length cannot be negative")
+	}
+
+	return nil
+}
+
+func (p WordPolicy) isLetter(r rune) bool {
+	if !unicode.IsLetter(r) {
+		return false
+	}
+
+	if len(p.Scripts) == 0 {
+		return true
+	}
+
+	for _, name := range p.Scripts {
+		if script, ok := lookupScript(name); ok && unicode.Is(unicode.Scripts[script], r) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (p WordPolicy) allows(r rune) bool {
+	switch {
+	case r == ' ' || r == '-' || unicode.IsMark(r):
+		return true
+
+	case p.Apostrophes && (r == apostrophes[0] || r == apostrophes[1]):
+		return true
+
+	case p.Periods && r == '.':
+		return true
+
+	case p.Digits && unicode.IsDigit(r):
+		return true
+	}
+
+	return p.isLetter(r)
+}
+
+// Validate explains why the word is not accepted, or returns nil.
+func (p WordPolicy) Validate(word string) error {
+	if word == "" {
+		return errors.New("it is empty")
+	}
+
+	if p.MaxLength > 0 && utf8.RuneCountInString(word) > p.MaxLength {
+		return fmt.Errorf("it is longer than %d characters", p.MaxLength)
+	}
+
+	hasLetter := false
+
+	for _, r := range word {
+		if !p.allows(r) {
+			return fmt.Errorf("it contains %q", r)
+		}
+
+		hasLetter = hasLetter || p.isLetter(r)
+	}
+
+	if !hasLetter {
+		return errors.New("it has no letters")
+	}
+
+	return nil
+}
+
+// Pattern returns a regular expression matching the characters a word may
+// consist of, for validating input before it reaches the dictionary.
+func (p WordPolicy) Pattern() string {
+	var b strings.Builder
+	b.WriteString(`[`)
+
+	if len(p.Scripts) == 0 {
+		b.WriteString(`\p{L}`)
+	}
+
+	for _, name := range p.Scripts {
+		if script, ok := lookupScript(name); ok {
+			fmt.Fprintf(&b, `\p{%s}`, script)
+		}
+	}
+
+	b.WriteString(`\p{M} \-`)
+
+	if p.Apostrophes {
+		b.WriteString(`'’`)
+	}
+
+	if p.Periods {
+		b.WriteString(`.`)
+	}
+
+	if p.Digits {
+		b.WriteString(`\p{Nd}`)
+	}
+
+	b.WriteString(`]`)
+
+	if p.MaxLength > 0 && p.MaxLength <= maxPatternRepeat {
+		fmt.Fprintf(&b, `{1,%d}`, p.MaxLength)
+	} else {
+		b.WriteString(`+`)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,19 @@
+package corepkg
+
+import "synodict-go/internal/structpkg"
+
+// Core is the Dict behind a synodict.Dictionary. Only internal packages
+// can create one, so only they can keep a handle on the Dict of the
+// Dictionary they build from it and use what the public API leaves out,
+// such as the change log.
+type Core struct {
+	dict *structpkg.Dict
+}
+
+func New() Core {
+	return Core{dict: structpkg.NewDict()}
+}
+
+func (c Core) Dict() *structpkg.Dict {
+	return c.dict
+}
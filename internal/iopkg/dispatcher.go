@@ -6,11 +6,10 @@ import (
 	"hash/fnv"
 	"os"
 	"regexp"
-	"strings"
 	"synodict-go/internal/common"
 )
 
-const exitCmd = "done"
+const ExitCmd = "done"
 
 var reader = bufio.NewReader(os.Stdin)
 
@@ -60,13 +59,18 @@ func scan(regexes, errPrompts []string) (string, bool) {
 			return "", true
 		}
 
-		input = strings.ToLower(strings.TrimSpace(input))
+		input, err = Normalize(input)
 
-		if input == exitCmd {
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "~ ERROR ~ incorrect syntax: %s\n", err)
+			continue
+		}
+
+		if input == ExitCmd {
 			return "", true
 		}
 
-		if validateByRegex(input, regexes) {
+		if ValidateByRegex(input, regexes) {
 			return input, false
 		}
 
@@ -82,7 +86,21 @@ func scan(regexes, errPrompts []string) (string, bool) {
 	}
 }
 
-func validateByRegex(input string, regexes []string) bool {
+// Normalize reads the input as a command line and writes it back in the
+// canonical form, so commands and flags are case-insensitive while quoted
+// words and paths are kept as typed (the dictionary normalizes words
+// itself) and can be validated by regular expressions.
+func Normalize(input string) (string, error) {
+	tokens, err := Tokenize(input)
+
+	if err != nil {
+		return "", err
+	}
+
+	return Canonical(tokens), nil
+}
+
+func ValidateByRegex(input string, regexes []string) bool {
 	if len(regexes) == 0 {
 		return true
 	}
@@ -0,0 +1,170 @@
+package iopkg
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Token is one argument of a command line. Raw is the argument exactly as
+// typed, quotes and backslashes included; Quoted is set if any part of it
+// was quoted; Column is where it starts, counting characters from 1.
+type Token struct {
+	Value  string
+	Raw    string
+	Quoted bool
+	Column int
+}
+
+type SyntaxError struct {
+	Column int
+	Msg    string
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("column %d: %s", e.Column, e.Msg)
+}
+
+// Tokenize splits a command line the way a shell does: arguments are
+// separated by whitespace, double quotes keep spaces and allow \" and \\
+// escapes, single quotes keep everything literally, a backslash outside
+// quotes escapes the next character, and adjacent parts join into one
+// argument ("ice"' 'cream is "ice cream").
+func Tokenize(input string) ([]Token, error) {
+	runes := []rune(input)
+	tokens := []Token{}
+
+	var value strings.Builder
+	var token *Token
+
+	start := func(i int) {
+		if token == nil {
+			token = &Token{Column: i + 1}
+		}
+	}
+
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+
+		switch {
+		case unicode.IsSpace(r):
+			if token != nil {
+				token.Value = value.String()
+				token.Raw = string(runes[token.Column-1 : i])
+				tokens = append(tokens, *token)
+				token = nil
+				value.Reset()
+			}
+
+		case r == '\\':
+			start(i)
+
+			if i+1 == len(runes) {
+				return nil, &SyntaxError{Column: i + 1, Msg: "nothing to escape after backslash"}
+			}
+
+			i++
+			value.WriteRune(runes[i])
+
+		case r == '\'':
+			start(i)
+			token.Quoted = true
+			end := i + 1
+
+			for end < len(runes) && runes[end] != '\'' {
+				end++
+			}
+
+			if end == len(runes) {
+				return nil, &SyntaxError{Column: i + 1, Msg: "unterminated single quote"}
+			}
+
+			value.WriteString(string(runes[i+1 : end]))
+			i = end
+
+		case r == '"':
+			start(i)
+			token.Quoted = true
+			end := i + 1
+
+			for ; end < len(runes) && runes[end] != '"'; end++ {
+				if runes[end] == '\\' && end+1 < len(runes) && (runes[end+1] == '"' || runes[end+1] == '\\') {
+					end++
+				}
+
+				value.WriteRune(runes[end])
+			}
+
+			if end == len(runes) {
+				return nil, &SyntaxError{Column: i + 1, Msg: "unterminated double quote"}
+			}
+
+			i = end
+
+		default:
+			start(i)
+			value.WriteRune(r)
+		}
+	}
+
+	if token != nil {
+		token.Value = value.String()
+		token.Raw = string(runes[token.Column-1:])
+		tokens = append(tokens, *token)
+	}
+
+	return tokens, nil
+}
+
+var quoteEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+func escapeBare(value string) string {
+	var b strings.Builder
+
+	for _, r := range value {
+		if r == '\\' || r == '"' || r == '\'' || unicode.IsSpace(r) {
+			b.WriteRune('\\')
+		}
+
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
+
+// isKeyword reports whether a bare argument is a command, flag, format or
+// number: letters, digits and hyphens only.
+func isKeyword(raw string) bool {
+	for _, r := range raw {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '-' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Canonical writes the tokens back as one line: bare keywords in lower
+// case, so that commands and flags are case-insensitive, other bare
+// arguments exactly as typed, neither unescaped nor lower-cased, so that
+// patterns such as /^a\.b$/ or fast\* reach the command unchanged, and
+// quoted ones in double quotes with the value they were given. Tokenize
+// reads the line back into those values.
+func Canonical(tokens []Token) string {
+	parts := make([]string, len(tokens))
+
+	for i, token := range tokens {
+		switch {
+		case token.Quoted:
+			parts[i] = `"` + quoteEscaper.Replace(token.Value) + `"`
+
+		case isKeyword(token.Raw):
+			parts[i] = strings.ToLower(token.Raw)
+
+		default:
+			parts[i] = escapeBare(token.Raw)
+		}
+	}
+
+	return strings.Join(parts, " ")
+}
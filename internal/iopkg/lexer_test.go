@@ -0,0 +1,166 @@
+package iopkg
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []Token
+	}{
+		{"empty", "", []Token{}},
+		{"blank", " \t ", []Token{}},
+		{"bare", "add fast  quick", []Token{{"add", "add", false, 1}, {"fast", "fast", false, 5}, {"quick", "quick", false, 11}}},
+		{"double quotes", `add "ice cream"`, []Token{{"add", "add", false, 1}, {"ice cream", `"ice cream"`, true, 5}}},
+		{"single quotes", `'say "hi"'`, []Token{{`say "hi"`, `'say "hi"'`, true, 1}}},
+		{"empty quotes", `"" ''`, []Token{{"", `""`, true, 1}, {"", `''`, true, 4}}},
+		{"escaped quote", `"say \"hi\""`, []Token{{`say "hi"`, `"say \"hi\""`, true, 1}}},
+		{"escaped backslash", `"a\\b"`, []Token{{`a\b`, `"a\\b"`, true, 1}}},
+		{"other backslash kept", `"a\b"`, []Token{{`a\b`, `"a\b"`, true, 1}}},
+		{"single quotes keep backslashes", `'a\"b'`, []Token{{`a\"b`, `'a\"b'`, true, 1}}},
+		{"bare escapes", `ice\ cream \"x\'`, []Token{{"ice cream", `ice\ cream`, false, 1}, {`"x'`, `\"x\'`, false, 12}}},
+		{"bare regex", `/^a\.b\D$/`, []Token{{`/^a.bD$/`, `/^a\.b\D$/`, false, 1}}},
+		{"adjacent parts", `"ice"' 'cream`, []Token{{"ice cream", `"ice"' 'cream`, true, 1}}},
+		{"bare then quoted", `--path="my file"`, []Token{{"--path=my file", `--path="my file"`, true, 1}}},
+		{"columns count characters", `"café" x`, []Token{{"café", `"café"`, true, 1}, {"x", "x", false, 8}}},
+		{"case kept", "Add Fast", []Token{{"Add", "Add", false, 1}, {"Fast", "Fast", false, 5}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Tokenize(tt.input)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Tokenize(%q) = %+v, want %+v", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTokenizeErrors(t *testing.T) {
+	tests := []struct {
+		input  string
+		column int
+		msg    string
+	}{
+		{`add "ice cream`, 5, "unterminated double quote"},
+		{`add 'ice cream`, 5, "unterminated single quote"},
+		{`"é" 'x`, 5, "unterminated single quote"},
+		{`"a\"`, 1, "unterminated double quote"},
+		{`add fast\`, 9, "nothing to escape after backslash"},
+		{`ok "x" "y`, 8, "unterminated double quote"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			_, err := Tokenize(tt.input)
+			var syntaxErr *SyntaxError
+
+			if !errors.As(err, &syntaxErr) {
+				t.Fatalf("Tokenize(%q) error = %v, want a SyntaxError", tt.input, err)
+			}
+
+			if syntaxErr.Column != tt.column || syntaxErr.Msg != tt.msg {
+				t.Errorf("Tokenize(%q) error = %v, want column %d: %s", tt.input, err, tt.column, tt.msg)
+			}
+		})
+	}
+}
+
+func TestCanonical(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"ADD Fast --FORCE", "add fast --force"},
+		{`add "Ice Cream"`, `add "Ice Cream"`},
+		{`'say "hi"'`, `"say \"hi\""`},
+		{`"a\b" 'c\d'`, `"a\\b" "c\\d"`},
+		{`"ice"' 'cream`, `"ice cream"`},
+		{`"" x`, `"" x`},
+		{`search /^a\.b$/`, `search /^a\\.b$/`},
+		{`search /\D\S\W/`, `search /\\D\\S\\W/`},
+		{`search Fast\*`, `search Fast\\*`},
+		{`ice\ Cream`, `ice\\\ Cream`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			tokens, err := Tokenize(tt.input)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if got := Canonical(tokens); got != tt.want {
+				t.Errorf("Canonical(Tokenize(%q)) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestCanonicalRoundTrip reads every line back from its canonical form:
+// quoted arguments keep their value, bare keywords are lower-cased and
+// other bare arguments come back exactly as typed.
+func TestCanonicalRoundTrip(t *testing.T) {
+	inputs := []string{
+		"ADD Fast",
+		`add "ice cream" 'say "hi"'`,
+		`"a\\b" 'c\d' "" x`,
+		`search /^a\.b$/ --limit 5`,
+		`search /\D+\s\W/`,
+		`search Fast\* f?st [a-f]*`,
+		`ice\ cream \"x\'`,
+		"tab\\\there",
+		`import json My\File.json`,
+		`"ice"' 'cream`,
+	}
+
+	for _, input := range inputs {
+		t.Run(input, func(t *testing.T) {
+			tokens, err := Tokenize(input)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			line := Canonical(tokens)
+			got, err := Tokenize(line)
+
+			if err != nil {
+				t.Fatalf("Tokenize(%q) failed: %v", line, err)
+			}
+
+			if len(got) != len(tokens) {
+				t.Fatalf("Tokenize(%q) = %+v, want %d tokens", line, got, len(tokens))
+			}
+
+			for i, token := range tokens {
+				want := token.Value
+
+				switch {
+				case token.Quoted:
+
+				case isKeyword(token.Raw):
+					want = strings.ToLower(token.Raw)
+
+				default:
+					want = token.Raw
+				}
+
+				if got[i].Value != want || got[i].Quoted != token.Quoted {
+					t.Errorf("token %d of %q = %+v, want value %q", i, line, got[i], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,285 @@
+package srvpkg
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/signal"
+	"synodict-go/synodict"
+	"syscall"
+	"time"
+)
+
+const maxBodySize = 32 << 20
+
+var formatContentTypes = map[synodict.Format]string{
+	"gob":   "application/octet-stream",
+	"csv":   "text/csv; charset=utf-8",
+	"csvc":  "text/csv; charset=utf-8",
+	"json":  "application/json",
+	"jsonl": "application/x-ndjson",
+}
+
+type Server struct {
+	dict *synodict.Dictionary
+}
+
+type errorResponse struct {
+	Errors []synodict.ErrorInfo `json:"errors"`
+}
+
+type synonymsRequest struct {
+	Words []string `json:"words"`
+}
+
+func NewServer(d *synodict.Dictionary) *Server {
+	return &Server{dict: d}
+}
+
+// helpers
+func writeJson(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func describeErrors(errs ...error) []synodict.ErrorInfo {
+	infos := []synodict.ErrorInfo{}
+
+	for _, err := range errs {
+		// one object per word for errors that report several at once
+		if joined, ok := err.(interface{ Unwrap() []error }); ok {
+			for _, e := range joined.Unwrap() {
+				infos = append(infos, synodict.DescribeError(e))
+			}
+
+			continue
+		}
+
+		infos = append(infos, synodict.DescribeError(err))
+	}
+
+	return infos
+}
+
+func writeErrors(w http.ResponseWriter, status int, errs ...error) {
+	writeJson(w, status, errorResponse{Errors: describeErrors(errs...)})
+}
+
+func nonNil(items []string) []string {
+	if items == nil {
+		return []string{}
+	}
+
+	return items
+}
+
+func formatParam(r *http.Request) (synodict.Format, error) {
+	name := r.URL.Query().Get("format")
+
+	if name == "" {
+		return synodict.FormatJSON, nil
+	}
+
+	format, ok := synodict.ParseFormat(name)
+
+	if !ok {
+		return "", fmt.Errorf("format %s is not supported", name)
+	}
+
+	return format, nil
+}
+
+// handlers
+func (s *Server) getSynonyms(w http.ResponseWriter, r *http.Request) {
+	word := r.PathValue("word")
+
+	synonyms, err := s.dict.Synonyms(word)
+
+	if err != nil {
+		writeErrors(w, http.StatusNotFound, err)
+		return
+	}
+
+	writeJson(w, http.StatusOK, map[string]any{
+		"word":     word,
+		"synonyms": nonNil(synonyms),
+	})
+}
+
+func (s *Server) check(w http.ResponseWriter, r *http.Request) {
+	a := r.URL.Query().Get("a")
+	b := r.URL.Query().Get("b")
+
+	if a == "" || b == "" {
+		writeErrors(w, http.StatusBadRequest, errors.New("query parameters a and b are required"))
+		return
+	}
+
+	synonyms, err := s.dict.AreSynonyms(a, b)
+
+	if err != nil {
+		writeErrors(w, http.StatusNotFound, err)
+		return
+	}
+
+	writeJson(w, http.StatusOK, map[string]any{
+		"a":        a,
+		"b":        b,
+		"synonyms": synonyms,
+	})
+}
+
+func (s *Server) addSynonyms(w http.ResponseWriter, r *http.Request) {
+	var request synonymsRequest
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxBodySize))
+	dec.DisallowUnknownFields()
+
+	if err := dec.Decode(&request); err != nil {
+		writeErrors(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %w", err))
+		return
+	}
+
+	if len(request.Words) == 0 {
+		writeErrors(w, http.StatusBadRequest, errors.New("at least one word is required"))
+		return
+	}
+
+	// an invalid word rejects the whole request before anything is linked
+	if err := s.dict.ValidateWords(request.Words...); err != nil {
+		writeErrors(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	// the only errors left are pairs that were already linked, which leave
+	// the rest of the words linked
+	response := map[string]any{"words": request.Words}
+
+	if err := s.dict.AddSynonyms(request.Words...); err != nil {
+		response["errors"] = describeErrors(err)
+	}
+
+	writeJson(w, http.StatusOK, response)
+}
+
+func (s *Server) removeWord(w http.ResponseWriter, r *http.Request) {
+	err := s.dict.RemoveWords(r.PathValue("word"))
+
+	if err != nil {
+		writeErrors(w, http.StatusNotFound, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) getGroups(w http.ResponseWriter, r *http.Request) {
+	groups := s.dict.Groups()
+
+	if groups == nil {
+		groups = [][]string{}
+	}
+
+	writeJson(w, http.StatusOK, map[string]any{"groups": groups})
+}
+
+func (s *Server) exportDict(w http.ResponseWriter, r *http.Request) {
+	format, err := formatParam(r)
+
+	if err != nil {
+		writeErrors(w, http.StatusBadRequest, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", formatContentTypes[format])
+	w.Header().Set("Content-Disposition", "attachment; filename=dict"+format.Extension())
+
+	err = s.dict.Export(w, format)
+
+	if err != nil {
+		writeErrors(w, http.StatusInternalServerError, err)
+	}
+}
+
+func (s *Server) importDict(w http.ResponseWriter, r *http.Request) {
+	format, err := formatParam(r)
+
+	if err != nil {
+		writeErrors(w, http.StatusBadRequest, err)
+		return
+	}
+
+	mode := r.URL.Query().Get("mode")
+
+	if mode != "" && mode != "merge" && mode != "overwrite" {
+		writeErrors(w, http.StatusBadRequest, fmt.Errorf("mode %s is not supported (expected merge or overwrite)", mode))
+		return
+	}
+
+	// decoded before anything changes, so a malformed body leaves the
+	// dictionary as it was, and applied as one change
+	pending, err := s.dict.ReadImport(http.MaxBytesReader(w, r.Body, maxBodySize), format)
+
+	if err != nil {
+		writeErrors(w, http.StatusUnprocessableEntity, err)
+		return
+	}
+
+	if mode == "" {
+		// checked together with the import, so a word added meanwhile is
+		// never overwritten
+		if err := pending.ApplyIfEmpty(); err != nil {
+			writeErrors(w, http.StatusConflict, fmt.Errorf("%w, use mode=merge or mode=overwrite", err))
+			return
+		}
+	} else {
+		pending.Apply(mode == "overwrite")
+	}
+
+	writeJson(w, http.StatusOK, map[string]any{"words": s.dict.WordCount()})
+}
+
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+
+	mux.HandleFunc("GET /words/{word}/synonyms", s.getSynonyms)
+	mux.HandleFunc("DELETE /words/{word}", s.removeWord)
+	mux.HandleFunc("GET /check", s.check)
+	mux.HandleFunc("POST /synonyms", s.addSynonyms)
+	mux.HandleFunc("GET /groups", s.getGroups)
+	mux.HandleFunc("GET /export", s.exportDict)
+	mux.HandleFunc("POST /import", s.importDict)
+
+	return mux
+}
+
+func (s *Server) ListenAndServe(addr string) error {
+	server := &http.Server{
+		Addr:              addr,
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	errCh := make(chan error, 1)
+
+	go func() {
+		errCh <- server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+
+	case <-ctx.Done():
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+
+		return server.Shutdown(shutdownCtx)
+	}
+}
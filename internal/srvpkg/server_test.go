@@ -0,0 +1,291 @@
+package srvpkg
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"synodict-go/synodict"
+	"testing"
+)
+
+func newTestServer(t *testing.T, groups ...[]string) (*httptest.Server, *synodict.Dictionary) {
+	t.Helper()
+
+	d := synodict.New()
+
+	for _, group := range groups {
+		if err := d.AddSynonyms(group...); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	server := httptest.NewServer(NewServer(d).Handler())
+	t.Cleanup(server.Close)
+
+	return server, d
+}
+
+func do(t *testing.T, server *httptest.Server, method, path, body string) (*http.Response, []byte) {
+	t.Helper()
+
+	request, err := http.NewRequest(method, server.URL+path, strings.NewReader(body))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	response, err := server.Client().Do(request)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer response.Body.Close()
+
+	data, err := io.ReadAll(response.Body)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return response, data
+}
+
+func decode(t *testing.T, data []byte) map[string]any {
+	t.Helper()
+
+	var v map[string]any
+
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatalf("invalid json %q: %v", data, err)
+	}
+
+	return v
+}
+
+func errorCode(t *testing.T, data []byte) string {
+	t.Helper()
+
+	errs, _ := decode(t, data)["errors"].([]any)
+
+	if len(errs) == 0 {
+		t.Fatalf("no errors in %s", data)
+	}
+
+	code, _ := errs[0].(map[string]any)["code"].(string)
+
+	return code
+}
+
+func TestEndpointStatuses(t *testing.T) {
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		status int
+		code   string
+	}{
+		{"synonyms", "GET", "/words/fast/synonyms", "", http.StatusOK, ""},
+		{"synonyms of unknown word", "GET", "/words/slow/synonyms", "", http.StatusNotFound, "word_not_found"},
+		{"check", "GET", "/check?a=fast&b=quick", "", http.StatusOK, ""},
+		{"check without words", "GET", "/check?a=fast", "", http.StatusBadRequest, "error"},
+		{"check unknown word", "GET", "/check?a=fast&b=slow", "", http.StatusNotFound, "word_not_found"},
+		{"add", "POST", "/synonyms", `{"words": ["big", "large"]}`, http.StatusOK, ""},
+		{"add without words", "POST", "/synonyms", `{"words": []}`, http.StatusBadRequest, "error"},
+		{"add malformed body", "POST", "/synonyms", `{"words":`, http.StatusBadRequest, "error"},
+		{"add unknown field", "POST", "/synonyms", `{"word": ["a"]}`, http.StatusBadRequest, "error"},
+		{"add invalid word", "POST", "/synonyms", `{"words": ["big", "12"]}`, http.StatusUnprocessableEntity, "invalid_word"},
+		{"remove", "DELETE", "/words/quick", "", http.StatusNoContent, ""},
+		{"remove unknown word", "DELETE", "/words/slow", "", http.StatusNotFound, "word_not_found"},
+		{"groups", "GET", "/groups", "", http.StatusOK, ""},
+		{"export unknown format", "GET", "/export?format=xml", "", http.StatusBadRequest, "error"},
+		{"import unknown format", "POST", "/import?format=xml&mode=merge", "", http.StatusBadRequest, "error"},
+		{"import unknown mode", "POST", "/import?mode=replace", "", http.StatusBadRequest, "error"},
+		{"import into non-empty dictionary", "POST", "/import", `{"synonyms": {}}`, http.StatusConflict, "not_empty"},
+		{"import malformed body", "POST", "/import?mode=merge", `{broken`, http.StatusUnprocessableEntity, "validation_failed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server, _ := newTestServer(t, []string{"fast", "quick"})
+			response, data := do(t, server, tt.method, tt.path, tt.body)
+
+			if response.StatusCode != tt.status {
+				t.Fatalf("status = %d, want %d (body %s)", response.StatusCode, tt.status, data)
+			}
+
+			if tt.code != "" {
+				if code := errorCode(t, data); code != tt.code {
+					t.Errorf("error code = %q, want %q", code, tt.code)
+				}
+			}
+		})
+	}
+}
+
+func TestAddSynonymsChangesNothingForInvalidWords(t *testing.T) {
+	server, d := newTestServer(t, []string{"fast", "quick"})
+	response, data := do(t, server, "POST", "/synonyms", `{"words": ["big", "large", "12"]}`)
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want %d (body %s)", response.StatusCode, http.StatusUnprocessableEntity, data)
+	}
+
+	if got := d.Words(); !reflect.DeepEqual(got, []string{"fast", "quick"}) {
+		t.Errorf("words after a rejected request = %v", got)
+	}
+
+	// pairs that are already linked are reported, the rest is linked
+	response, data = do(t, server, "POST", "/synonyms", `{"words": ["fast", "quick", "rapid"]}`)
+
+	if response.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d, want %d (body %s)", response.StatusCode, http.StatusOK, data)
+	}
+
+	if code := errorCode(t, data); code != "already_linked" {
+		t.Errorf("error code = %q, want already_linked", code)
+	}
+
+	if got := d.Words(); !reflect.DeepEqual(got, []string{"fast", "quick", "rapid"}) {
+		t.Errorf("words = %v, want [fast quick rapid]", got)
+	}
+}
+
+func TestSynonymsAndGroups(t *testing.T) {
+	server, _ := newTestServer(t, []string{"fast", "quick", "rapid"}, []string{"big", "large"})
+
+	_, data := do(t, server, "GET", "/words/fast/synonyms", "")
+
+	if got := decode(t, data)["synonyms"]; !reflect.DeepEqual(got, []any{"quick", "rapid"}) {
+		t.Errorf("synonyms = %v", got)
+	}
+
+	_, data = do(t, server, "GET", "/check?a=fast&b=big", "")
+
+	if got := decode(t, data)["synonyms"]; got != false {
+		t.Errorf("check fast/big = %v, want false", got)
+	}
+
+	_, data = do(t, server, "GET", "/groups", "")
+	want := []any{[]any{"big", "large"}, []any{"fast", "quick", "rapid"}}
+
+	if got := decode(t, data)["groups"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("groups = %v, want %v", got, want)
+	}
+}
+
+func TestUnknownWordSuggestions(t *testing.T) {
+	server, _ := newTestServer(t, []string{"word", "ward"})
+
+	_, data := do(t, server, "GET", "/words/wrod/synonyms", "")
+	errs := decode(t, data)["errors"].([]any)
+	first := errs[0].(map[string]any)
+
+	if !reflect.DeepEqual(first["suggestions"], []any{"word", "ward"}) {
+		t.Errorf("suggestions = %v", first["suggestions"])
+	}
+}
+
+func TestExportContentTypes(t *testing.T) {
+	server, _ := newTestServer(t, []string{"fast", "quick"})
+
+	for format, contentType := range formatContentTypes {
+		t.Run(string(format), func(t *testing.T) {
+			response, data := do(t, server, "GET", "/export?format="+string(format), "")
+
+			if response.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d (body %s)", response.StatusCode, data)
+			}
+
+			if got := response.Header.Get("Content-Type"); got != contentType {
+				t.Errorf("Content-Type = %q, want %q", got, contentType)
+			}
+
+			disposition := "attachment; filename=dict" + format.Extension()
+
+			if got := response.Header.Get("Content-Disposition"); got != disposition {
+				t.Errorf("Content-Disposition = %q, want %q", got, disposition)
+			}
+
+			// the export reads back into the same dictionary
+			d := synodict.New()
+
+			if err := d.Import(strings.NewReader(string(data)), format); err != nil {
+				t.Fatal(err)
+			}
+
+			if ok, _ := d.AreSynonyms("fast", "quick"); !ok {
+				t.Errorf("exported %s does not link fast and quick", format)
+			}
+		})
+	}
+
+	response, _ := do(t, server, "GET", "/export", "")
+
+	if got := response.Header.Get("Content-Type"); got != "application/json" {
+		t.Errorf("default export Content-Type = %q, want application/json", got)
+	}
+}
+
+func TestImportModes(t *testing.T) {
+	body := `{"synonyms": {"big": ["large"], "large": ["big"]}}`
+
+	tests := []struct {
+		mode  string
+		words []string
+	}{
+		{"merge", []string{"big", "fast", "large", "quick"}},
+		{"overwrite", []string{"big", "large"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			server, d := newTestServer(t, []string{"fast", "quick"})
+			response, data := do(t, server, "POST", "/import?format=json&mode="+tt.mode, body)
+
+			if response.StatusCode != http.StatusOK {
+				t.Fatalf("status = %d (body %s)", response.StatusCode, data)
+			}
+
+			if got := d.Words(); !reflect.DeepEqual(got, tt.words) {
+				t.Errorf("words = %v, want %v", got, tt.words)
+			}
+
+			if undone := d.Undo(1); len(undone) != 1 || !reflect.DeepEqual(d.Words(), []string{"fast", "quick"}) {
+				t.Errorf("one undo did not restore the dictionary: %v", d.Words())
+			}
+		})
+	}
+
+	t.Run("empty dictionary", func(t *testing.T) {
+		server, d := newTestServer(t)
+		response, data := do(t, server, "POST", "/import", body)
+
+		if response.StatusCode != http.StatusOK || d.WordCount() != 2 {
+			t.Errorf("status = %d, %d words (body %s)", response.StatusCode, d.WordCount(), data)
+		}
+	})
+}
+
+func TestFailedOverwriteKeepsDictionary(t *testing.T) {
+	server, d := newTestServer(t, []string{"fast", "quick"})
+	response, _ := do(t, server, "POST", "/import?mode=overwrite&format=json", `{broken`)
+
+	if response.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("status = %d, want %d", response.StatusCode, http.StatusUnprocessableEntity)
+	}
+
+	if got := d.Words(); !reflect.DeepEqual(got, []string{"fast", "quick"}) {
+		t.Errorf("words after a failed import = %v", got)
+	}
+
+	_, data := do(t, server, "GET", "/groups", "")
+
+	if got := decode(t, data)["groups"]; !reflect.DeepEqual(got, []any{[]any{"fast", "quick"}}) {
+		t.Errorf("groups after a failed import = %v", got)
+	}
+}
@@ -1,36 +1,281 @@
 package stgpkg
 
 import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"errors"
 	"fmt"
+	"hash"
+	"io"
 	"os"
+	"path/filepath"
 )
 
 var BOM = []byte{0xEF, 0xBB, 0xBF}
 
-func Write(data []byte, path string, addBom bool) error {
+type readCloser struct {
+	io.Reader
+	io.Closer
+}
+
+// AtomicFile collects writes in a temporary file next to the target and
+// only replaces the target on Close, so a failed or interrupted write never
+// leaves a truncated file behind.
+type AtomicFile struct {
+	file    *os.File
+	hash    hash.Hash
+	path    string
+	backups int
+	err     error
+	done    bool
+}
+
+func backupPath(path string, i int) string {
+	return fmt.Sprintf("%s.bak.%d", path, i)
+}
+
+func SkipBOM(r io.Reader) io.Reader {
+	br := bufio.NewReader(r)
+	prefix, _ := br.Peek(len(BOM))
+
+	if bytes.Equal(prefix, BOM) {
+		br.Discard(len(BOM))
+	}
+
+	return br
+}
+
+// Create starts writing path; on Close the previous version is kept as
+// the first of up to backups rotated backups.
+func Create(path string, addBom bool, backups int) (*AtomicFile, error) {
+	dir, base := filepath.Split(path)
+
+	if dir == "" {
+		dir = "."
+	}
+
+	file, err := os.CreateTemp(dir, "."+base+".tmp-*")
+
+	if err != nil {
+		return nil, fmt.Errorf("file write failed: %w", err)
+	}
+
+	f := &AtomicFile{file: file, hash: sha256.New(), path: path, backups: max(backups, 0)}
+
 	if addBom {
-		data = append(BOM, data...)
+		if _, err := f.Write(BOM); err != nil {
+			f.Abort()
+			return nil, err
+		}
+	}
+
+	return f, nil
+}
+
+func (f *AtomicFile) Write(p []byte) (int, error) {
+	if f.err != nil {
+		return 0, f.err
 	}
 
-	err := os.WriteFile(path, data, 0644)
+	n, err := f.file.Write(p)
+	f.hash.Write(p[:n])
 
 	if err != nil {
-		return fmt.Errorf("file write failed: %w", err)
+		f.err = fmt.Errorf("file write failed: %w", err)
 	}
 
+	return n, f.err
+}
+
+// Sum returns the SHA-256 checksum of everything written so far.
+func (f *AtomicFile) Sum() []byte {
+	return f.hash.Sum(nil)
+}
+
+// Abort discards everything written so far and leaves the target untouched.
+func (f *AtomicFile) Abort() {
+	if f.done {
+		return
+	}
+
+	f.done = true
+	f.file.Close()
+	os.Remove(f.file.Name())
+}
+
+// Close syncs the temporary file, rotates backups of the previous version
+// and renames the temporary file over the target.
+func (f *AtomicFile) Close() error {
+	if f.done {
+		return f.err
+	}
+
+	if f.err != nil {
+		f.Abort()
+		return f.err
+	}
+
+	err := f.commit()
+
+	if err != nil {
+		f.Abort()
+		f.err = fmt.Errorf("file write failed: %w", err)
+
+		return f.err
+	}
+
+	f.done = true
+
 	return nil
 }
 
-func Read(path string) ([]byte, error) {
-	data, err := os.ReadFile(path)
+func (f *AtomicFile) commit() error {
+	err := f.file.Sync()
+
+	if err != nil {
+		return err
+	}
+
+	err = f.file.Close()
 
 	if err != nil {
-		return data, fmt.Errorf("file read failed: %w", err)
+		return err
 	}
 
-	if len(data) >= 3 && data[0] == BOM[0] && data[1] == BOM[1] && data[2] == BOM[2] {
-		data = data[3:]
+	mode := os.FileMode(0644)
+
+	if info, err := os.Stat(f.path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	err = os.Chmod(f.file.Name(), mode)
+
+	if err != nil {
+		return err
+	}
+
+	err = rotateBackups(f.path, f.backups)
+
+	if err != nil {
+		return err
+	}
+
+	err = os.Rename(f.file.Name(), f.path)
+
+	if err != nil {
+		return err
+	}
+
+	return syncDir(filepath.Dir(f.path))
+}
+
+func rotateBackups(path string, n int) error {
+	if n == 0 {
+		return nil
+	}
+
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+
+	err := os.Remove(backupPath(path, n))
+
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+
+	for i := n - 1; i >= 1; i-- {
+		err := os.Rename(backupPath(path, i), backupPath(path, i+1))
+
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+	}
+
+	// a hard link keeps the target in place until the rename replaces it
+	err = os.Link(path, backupPath(path, 1))
+
+	if err != nil {
+		return copyFile(path, backupPath(path, 1))
+	}
+
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+
+	if err != nil {
+		return err
+	}
+
+	defer in.Close()
+
+	out, err := os.Create(dst)
+
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(out, in)
+
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+
+	return err
+}
+
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+
+	if err != nil {
+		return err
+	}
+
+	defer d.Close()
+
+	err = d.Sync()
+
+	// some platforms and file systems cannot sync directories
+	if errors.Is(err, os.ErrInvalid) || errors.Is(err, errors.ErrUnsupported) {
+		return nil
+	}
+
+	return err
+}
+
+// FileSum returns the SHA-256 checksum of the file, or nil if there is no
+// such file.
+func FileSum(path string) ([]byte, error) {
+	file, err := os.Open(path)
+
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("file read failed: %w", err)
+	}
+
+	defer file.Close()
+
+	h := sha256.New()
+
+	if _, err := io.Copy(h, file); err != nil {
+		return nil, fmt.Errorf("file read failed: %w", err)
+	}
+
+	return h.Sum(nil), nil
+}
+
+func Open(path string) (io.ReadCloser, error) {
+	file, err := os.Open(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("file read failed: %w", err)
 	}
 
-	return data, nil
+	return readCloser{Reader: SkipBOM(file), Closer: file}, nil
 }
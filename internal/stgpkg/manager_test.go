@@ -0,0 +1,214 @@
+package stgpkg
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+
+	writeVersion(t, path, content, 0)
+}
+
+func writeVersion(t *testing.T, path, content string, backups int) {
+	t.Helper()
+
+	f, err := Create(path, false, backups)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := io.WriteString(f, content); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readFile(t *testing.T, path string) string {
+	t.Helper()
+
+	data, err := os.ReadFile(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	return string(data)
+}
+
+// checkDir fails unless dir holds exactly the named files, so no temporary
+// file was left behind.
+func checkDir(t *testing.T, dir string, names ...string) {
+	t.Helper()
+
+	entries, err := os.ReadDir(dir)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+
+	for _, entry := range entries {
+		got = append(got, entry.Name())
+	}
+
+	if len(got) != len(names) {
+		t.Fatalf("directory holds %v, want %v", got, names)
+	}
+
+	for i := range names {
+		if got[i] != names[i] {
+			t.Fatalf("directory holds %v, want %v", got, names)
+		}
+	}
+}
+
+func TestFailedWritesLeaveTargetUntouched(t *testing.T) {
+	tests := []struct {
+		name    string
+		fail    func(t *testing.T, f *AtomicFile) error
+		wantErr bool
+	}{
+		{
+			name: "abort",
+			fail: func(t *testing.T, f *AtomicFile) error {
+				f.Abort()
+				return f.Close()
+			},
+		},
+		{
+			name: "write error",
+			fail: func(t *testing.T, f *AtomicFile) error {
+				// closing the temporary file makes the next write fail
+				f.file.Close()
+
+				if _, err := io.WriteString(f, "more"); err == nil {
+					t.Error("write to a closed file succeeded")
+				}
+
+				return f.Close()
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			path := filepath.Join(dir, "dict.json")
+			writeFile(t, path, "old")
+
+			f, err := Create(path, false, 0)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if _, err := io.WriteString(f, "new"); err != nil {
+				t.Fatal(err)
+			}
+
+			err = tt.fail(t, f)
+
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Close() error = %v, want error %v", err, tt.wantErr)
+			}
+
+			if got := readFile(t, path); got != "old" {
+				t.Errorf("target holds %q, want %q", got, "old")
+			}
+
+			checkDir(t, dir, "dict.json")
+		})
+	}
+}
+
+func TestBackupRotation(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "dict.json")
+
+	for _, version := range []string{"v1", "v2", "v3", "v4", "v5"} {
+		writeVersion(t, path, version, 3)
+	}
+
+	want := map[string]string{
+		path:                "v5",
+		backupPath(path, 1): "v4",
+		backupPath(path, 2): "v3",
+		backupPath(path, 3): "v2",
+	}
+
+	for file, content := range want {
+		if got := readFile(t, file); got != content {
+			t.Errorf("%s holds %q, want %q", filepath.Base(file), got, content)
+		}
+	}
+
+	checkDir(t, dir, "dict.json", "dict.json.bak.1", "dict.json.bak.2", "dict.json.bak.3")
+}
+
+func TestReplaceKeepsPermissions(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "dict.json")
+	writeFile(t, path, "old")
+
+	if info, err := os.Stat(path); err != nil || info.Mode().Perm() != 0644 {
+		t.Fatalf("new file mode = %v, %v; want 0644", info.Mode().Perm(), err)
+	}
+
+	if err := os.Chmod(path, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	writeFile(t, path, "new")
+	info, err := os.Stat(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if mode := info.Mode().Perm(); mode != 0600 {
+		t.Errorf("mode after replace = %v, want 0600", mode)
+	}
+}
+
+func TestBOMRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dict.csv")
+	f, err := Create(path, true, 0)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	io.WriteString(f, "a;b\n")
+
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := readFile(t, path); got != string(BOM)+"a;b\n" {
+		t.Errorf("file holds %q, want a BOM first", got)
+	}
+
+	r, err := Open(path)
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	defer r.Close()
+
+	data, _ := io.ReadAll(r)
+
+	if string(data) != "a;b\n" {
+		t.Errorf("Open read %q, want the BOM skipped", data)
+	}
+}
@@ -0,0 +1,287 @@
+package stgpkg
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"slices"
+)
+
+const walHeaderSize = 8
+const maxWalRecordSize = 16 << 20
+
+// checkpointFlag marks the length of a checkpoint record, which the
+// length of a record can never reach.
+const checkpointFlag = 1 << 31
+
+var crcTable = crc32.MakeTable(crc32.Castagnoli)
+
+// WAL is an append-only log of records. Every record is stored as its
+// length, a CRC-32C checksum and the payload, so a tail torn by a crash
+// or damaged on disk is detected on open and cut off.
+//
+// A checkpoint record names a snapshot by its checksum and says that the
+// records before an offset are part of it. It is written before the
+// snapshot replaces the previous one, so whichever of the two a crash
+// leaves behind, the log knows which of its records still apply.
+type WAL struct {
+	file      *os.File
+	path      string
+	size      int64
+	truncated int64
+}
+
+type walRecord struct {
+	payload    []byte
+	offset     int64
+	checkpoint bool
+}
+
+func appendRecord(buf, payload []byte, flag uint32) []byte {
+	buf = binary.LittleEndian.AppendUint32(buf, uint32(len(payload))|flag)
+	buf = binary.LittleEndian.AppendUint32(buf, crc32.Checksum(payload, crcTable))
+
+	return append(buf, payload...)
+}
+
+func readWal(r io.Reader) ([]walRecord, int64) {
+	br := bufio.NewReader(r)
+	header := make([]byte, walHeaderSize)
+	records := []walRecord{}
+	valid := int64(0)
+
+	for {
+		if _, err := io.ReadFull(br, header); err != nil {
+			return records, valid
+		}
+
+		size := binary.LittleEndian.Uint32(header[:4])
+		sum := binary.LittleEndian.Uint32(header[4:])
+		checkpoint := size&checkpointFlag != 0
+		size &^= checkpointFlag
+
+		if size > maxWalRecordSize {
+			return records, valid
+		}
+
+		payload := make([]byte, size)
+
+		if _, err := io.ReadFull(br, payload); err != nil {
+			return records, valid
+		}
+
+		if crc32.Checksum(payload, crcTable) != sum {
+			return records, valid
+		}
+
+		records = append(records, walRecord{payload: payload, offset: valid, checkpoint: checkpoint})
+		valid += walHeaderSize + int64(size)
+	}
+}
+
+// applicable drops the records that the last checkpoint of the snapshot
+// says are part of it.
+func applicable(records []walRecord, snapshot []byte) [][]byte {
+	skip := int64(0)
+
+	for _, record := range records {
+		if record.checkpoint && len(record.payload) == sha256.Size+8 && bytes.Equal(record.payload[:sha256.Size], snapshot) {
+			skip = int64(binary.LittleEndian.Uint64(record.payload[sha256.Size:]))
+		}
+	}
+
+	payloads := [][]byte{}
+
+	for _, record := range records {
+		if !record.checkpoint && record.offset >= skip {
+			payloads = append(payloads, record.payload)
+		}
+	}
+
+	return payloads
+}
+
+// OpenWAL opens or creates the log at path and returns its intact records
+// that are not part of the snapshot with the given checksum. Anything
+// after the last intact record is truncated.
+func OpenWAL(path string, snapshot []byte) (*WAL, [][]byte, error) {
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0644)
+
+	if err != nil {
+		return nil, nil, fmt.Errorf("log open failed: %w", err)
+	}
+
+	records, valid := readWal(file)
+	truncated := int64(0)
+	info, err := file.Stat()
+
+	if err == nil && info.Size() > valid {
+		truncated = info.Size() - valid
+		err = file.Truncate(valid)
+
+		if err == nil {
+			err = file.Sync()
+		}
+	}
+
+	if err == nil {
+		_, err = file.Seek(valid, io.SeekStart)
+	}
+
+	if err != nil {
+		file.Close()
+		return nil, nil, fmt.Errorf("log open failed: %w", err)
+	}
+
+	return &WAL{file: file, path: path, size: valid, truncated: truncated}, applicable(records, snapshot), nil
+}
+
+// Append writes the records and syncs them to disk. If the write fails,
+// the log is cut back to its previous size so that it never ends with a
+// partial record.
+func (w *WAL) Append(records [][]byte) error {
+	var buf []byte
+
+	for _, record := range records {
+		if len(record) > maxWalRecordSize {
+			return fmt.Errorf("log write failed: record of %d bytes is too large", len(record))
+		}
+
+		buf = appendRecord(buf, record, 0)
+	}
+
+	return w.write(buf)
+}
+
+// Checkpoint records that the records before offset are part of the
+// snapshot with the given checksum. Write it before the snapshot replaces
+// the previous one.
+func (w *WAL) Checkpoint(snapshot []byte, offset int64) error {
+	payload := binary.LittleEndian.AppendUint64(slices.Clone(snapshot), uint64(offset))
+
+	return w.write(appendRecord(nil, payload, checkpointFlag))
+}
+
+func (w *WAL) write(buf []byte) error {
+	_, err := w.file.Write(buf)
+
+	if err == nil {
+		err = w.file.Sync()
+	}
+
+	if err != nil {
+		err = errors.Join(err, w.file.Truncate(w.size))
+		w.file.Seek(w.size, io.SeekStart)
+
+		return fmt.Errorf("log write failed: %w", err)
+	}
+
+	w.size += int64(len(buf))
+
+	return nil
+}
+
+// Discard drops the records before offset, once they are part of a
+// snapshot, together with the checkpoints, which only matter until then.
+// The remaining records are moved into a new file that replaces the log
+// atomically.
+func (w *WAL) Discard(offset int64) error {
+	if offset >= w.size {
+		return w.reset()
+	}
+
+	tail := make([]byte, w.size-offset)
+	_, err := w.file.ReadAt(tail, offset)
+
+	if err != nil {
+		return fmt.Errorf("log compaction failed: %w", err)
+	}
+
+	records, _ := readWal(bytes.NewReader(tail))
+	var buf []byte
+
+	for _, record := range records {
+		if !record.checkpoint {
+			buf = appendRecord(buf, record.payload, 0)
+		}
+	}
+
+	file, err := Create(w.path, false, 0)
+
+	if err != nil {
+		return fmt.Errorf("log compaction failed: %w", err)
+	}
+
+	file.Write(buf)
+	err = file.Close()
+
+	if err == nil {
+		err = w.reopen()
+	}
+
+	if err != nil {
+		return fmt.Errorf("log compaction failed: %w", err)
+	}
+
+	return nil
+}
+
+func (w *WAL) reset() error {
+	err := w.file.Truncate(0)
+
+	if err == nil {
+		_, err = w.file.Seek(0, io.SeekStart)
+	}
+
+	if err == nil {
+		err = w.file.Sync()
+	}
+
+	if err != nil {
+		return fmt.Errorf("log compaction failed: %w", err)
+	}
+
+	w.size = 0
+
+	return nil
+}
+
+func (w *WAL) reopen() error {
+	file, err := os.OpenFile(w.path, os.O_RDWR, 0644)
+
+	if err != nil {
+		return err
+	}
+
+	size, err := file.Seek(0, io.SeekEnd)
+
+	if err != nil {
+		file.Close()
+		return err
+	}
+
+	w.file.Close()
+	w.file, w.size = file, size
+
+	return nil
+}
+
+// Truncated returns how many damaged bytes were cut off the end of the log
+// when it was opened.
+func (w *WAL) Truncated() int64 {
+	return w.truncated
+}
+
+func (w *WAL) Size() int64 {
+	return w.size
+}
+
+func (w *WAL) Close() error {
+	return w.file.Close()
+}
@@ -0,0 +1,68 @@
+package stgpkg
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"path/filepath"
+	"testing"
+)
+
+func TestCheckpoint(t *testing.T) {
+	oldSnapshot := sha256.Sum256([]byte("old"))
+	newSnapshot := sha256.Sum256([]byte("new"))
+
+	tests := []struct {
+		name     string
+		snapshot []byte
+		discard  bool
+		want     string
+	}{
+		{"crash before the snapshot replaced the old one", oldSnapshot[:], false, "[a b c]"},
+		{"crash before the log was cut back", newSnapshot[:], false, "[c]"},
+		{"log cut back", newSnapshot[:], true, "[c]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "dict.wal")
+			wal, _, err := OpenWAL(path, oldSnapshot[:])
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if err := wal.Append([][]byte{[]byte("a"), []byte("b")}); err != nil {
+				t.Fatal(err)
+			}
+
+			offset := wal.Size()
+
+			if err := wal.Append([][]byte{[]byte("c")}); err != nil {
+				t.Fatal(err)
+			}
+
+			if err := wal.Checkpoint(newSnapshot[:], offset); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.discard {
+				if err := wal.Discard(offset); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			wal.Close()
+			wal, records, err := OpenWAL(path, tt.snapshot)
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			defer wal.Close()
+
+			if got := fmt.Sprintf("%s", records); got != tt.want {
+				t.Errorf("records after reopening = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package structpkg
+
+import (
+	"bytes"
+	"fmt"
+	"math/rand"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+const (
+	stressWorkers    = 8
+	stressIterations = 300
+	stressWords      = 40
+)
+
+func stressWord(i int) string {
+	return fmt.Sprintf("word %c%c", 'a'+i/26, 'a'+i%26)
+}
+
+// TestConcurrentUse mixes writers, readers, exports and imports on one
+// Dict; run it with -race.
+func TestConcurrentUse(t *testing.T) {
+	d := NewDict()
+	dir := t.TempDir()
+	seed := filepath.Join(dir, "seed.json")
+
+	if err := d.AddSynonyms(stressWord(0), stressWord(1)); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.Export(seed, "json"); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+
+	for worker := range stressWorkers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			rng := rand.New(rand.NewSource(int64(worker)))
+			word := func() string { return stressWord(rng.Intn(stressWords)) }
+			export := filepath.Join(dir, fmt.Sprintf("export-%d.json", worker))
+
+			for range stressIterations {
+				// errors for words another worker removed are expected
+				switch rng.Intn(10) {
+				case 0, 1, 2:
+					_ = d.AddSynonyms(word(), word())
+				case 3:
+					_ = d.RemoveWords(word())
+				case 4, 5:
+					_, _ = d.AreSynonyms(word(), word())
+				case 6:
+					d.GetSynonymGroups()
+				case 7:
+					d.Suggest(word(), 3)
+					_, _ = d.Search("word a*", 0, 5)
+				case 8:
+					if err := d.Export(export, "json"); err != nil {
+						t.Error(err)
+					}
+				case 9:
+					if err := d.Import(seed, "json"); err != nil {
+						t.Error(err)
+					}
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	checkGroups(t, d)
+}
+
+// TestReadersDuringImport keeps readers running while another goroutine
+// replaces the dictionary with decoded graphs.
+func TestReadersDuringImport(t *testing.T) {
+	d := NewDict()
+	var data bytes.Buffer
+
+	for i := 1; i < stressWords; i++ {
+		if err := d.AddSynonyms(stressWord(0), stressWord(i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if err := d.Encode(&data, "gob"); err != nil {
+		t.Fatal(err)
+	}
+
+	done := make(chan struct{})
+	var wg sync.WaitGroup
+
+	for range stressWorkers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+
+				ok, err := d.AreSynonyms(stressWord(0), stressWord(stressWords-1))
+
+				if err == nil && !ok {
+					t.Error("readers saw a partially imported dictionary")
+					return
+				}
+
+				d.GetSynonymGroups()
+			}
+		}()
+	}
+
+	for i := range stressIterations {
+		graph, err := d.DecodeGraph(bytes.NewReader(data.Bytes()), "gob")
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		d.Apply(graph, "stress.gob", i%2 == 0)
+	}
+
+	close(done)
+	wg.Wait()
+
+	checkGroups(t, d)
+}
+
+// checkGroups verifies that the connectivity index agrees with the
+// adjacency lists once all goroutines have finished.
+func checkGroups(t *testing.T, d *Dict) {
+	t.Helper()
+
+	groups := d.GetSynonymGroups()
+
+	if want := bfsGroupCount(d.graph); len(groups) != want {
+		t.Errorf("%d synonym groups, want %d", len(groups), want)
+	}
+
+	for _, group := range groups {
+		for _, word := range group[1:] {
+			if ok, err := d.AreSynonyms(group[0], word); err != nil || !ok {
+				t.Errorf("AreSynonyms(%q, %q) = %v, %v", group[0], word, ok, err)
+			}
+		}
+	}
+}
+
+// TestCrossedDiffs runs Diffs in both directions between two Dicts while
+// both change; with both locks held at once the Diffs would deadlock.
+func TestCrossedDiffs(t *testing.T) {
+	a, b := NewDict(), NewDict()
+	var wg sync.WaitGroup
+
+	for worker := range stressWorkers {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range stressIterations / 3 {
+				switch worker % 4 {
+				case 0:
+					a.Diff(b)
+				case 1:
+					b.Diff(a)
+				case 2:
+					_ = a.AddSynonyms(stressWord(i%stressWords), stressWord((i+1)%stressWords))
+				case 3:
+					_ = b.AddSynonyms(stressWord(i%stressWords), stressWord((i+2)%stressWords))
+				}
+			}
+		}()
+	}
+
+	wg.Wait()
+}
@@ -0,0 +1,109 @@
+package structpkg
+
+type connectivityIndex struct {
+	parent map[string]string
+	size   map[string]int
+	count  int
+	valid  bool
+}
+
+func newConnectivityIndex() *connectivityIndex {
+	return &connectivityIndex{
+		parent: make(map[string]string),
+		size:   make(map[string]int),
+	}
+}
+
+func (ci *connectivityIndex) add(vertex string) {
+	if _, ok := ci.parent[vertex]; ok {
+		return
+	}
+
+	ci.parent[vertex] = vertex
+	ci.size[vertex] = 1
+	ci.count++
+}
+
+func (ci *connectivityIndex) removeSingleton(vertex string) {
+	root, ok := ci.parent[vertex]
+
+	if !ok || root != vertex || ci.size[vertex] != 1 {
+		ci.valid = false
+		return
+	}
+
+	delete(ci.parent, vertex)
+	delete(ci.size, vertex)
+	ci.count--
+}
+
+func (ci *connectivityIndex) find(vertex string) string {
+	root := vertex
+
+	for ci.parent[root] != root {
+		root = ci.parent[root]
+	}
+
+	for vertex != root {
+		next := ci.parent[vertex]
+		ci.parent[vertex] = root
+		vertex = next
+	}
+
+	return root
+}
+
+func (ci *connectivityIndex) union(a, b string) {
+	rootA := ci.find(a)
+	rootB := ci.find(b)
+
+	if rootA == rootB {
+		return
+	}
+
+	if ci.size[rootA] < ci.size[rootB] {
+		rootA, rootB = rootB, rootA
+	}
+
+	ci.parent[rootB] = rootA
+	ci.size[rootA] += ci.size[rootB]
+	delete(ci.size, rootB)
+	ci.count--
+}
+
+func (ci *connectivityIndex) componentSize(vertex string) int {
+	if _, ok := ci.parent[vertex]; !ok {
+		return 0
+	}
+
+	return ci.size[ci.find(vertex)]
+}
+
+func (g *Graph) invalidateIndex() {
+	g.index.valid = false
+}
+
+func (g *Graph) connectivity() *connectivityIndex {
+	if g.index.valid {
+		return g.index
+	}
+
+	index := newConnectivityIndex()
+
+	for vertex := range g.adj {
+		index.add(vertex)
+	}
+
+	for vertex, neighbors := range g.adj {
+		for neighbor := range neighbors {
+			if neighbor > vertex {
+				index.union(vertex, neighbor)
+			}
+		}
+	}
+
+	index.valid = true
+	g.index = index
+
+	return index
+}
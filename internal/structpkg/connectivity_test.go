@@ -0,0 +1,178 @@
+package structpkg
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+)
+
+const (
+	benchGroups    = 2000
+	benchGroupSize = 50
+)
+
+// benchGraph builds benchGroups synonym groups of benchGroupSize words,
+// each a random tree plus a few extra links, and returns a pair of words
+// from the same group and one from different groups.
+func benchGraph(b *testing.B) (*Graph, [2]string, [2]string) {
+	b.Helper()
+
+	rng := rand.New(rand.NewSource(1))
+	g := NewGraph()
+	word := func(group, i int) string { return fmt.Sprintf("w%d-%d", group, i) }
+
+	for group := range benchGroups {
+		if err := g.AddVertex(word(group, 0)); err != nil {
+			b.Fatal(err)
+		}
+
+		for i := 1; i < benchGroupSize; i++ {
+			if err := g.AddEdge(word(group, i), word(group, rng.Intn(i))); err != nil {
+				b.Fatal(err)
+			}
+		}
+
+		for range benchGroupSize / 10 {
+			if err := g.AddEdge(word(group, rng.Intn(benchGroupSize)), word(group, rng.Intn(benchGroupSize))); err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+
+	same := [2]string{word(0, 0), word(0, benchGroupSize-1)}
+	different := [2]string{word(0, 0), word(benchGroups-1, 0)}
+
+	return g, same, different
+}
+
+func bfsGroupCount(g *Graph) int {
+	seen := make(map[string]bool, len(g.adj))
+	count := 0
+
+	for vertex := range g.adj {
+		if seen[vertex] {
+			continue
+		}
+
+		for v := range g.bfs(vertex) {
+			seen[v] = true
+		}
+
+		count++
+	}
+
+	return count
+}
+
+func TestConnectivityIndexMatchesBfs(t *testing.T) {
+	g := NewGraph()
+
+	for _, edge := range [][2]string{{"a", "b"}, {"b", "c"}, {"d", "e"}, {"f", "f"}} {
+		if err := g.AddEdge(edge[0], edge[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check := func() {
+		t.Helper()
+
+		if got, want := g.ConnectivityGroupCount(), bfsGroupCount(g); got != want {
+			t.Errorf("ConnectivityGroupCount() = %d, bfs counts %d", got, want)
+		}
+
+		for a := range g.adj {
+			if got, want := g.ConnectedVertexCount(a), len(g.bfs(a))-1; got != want {
+				t.Errorf("ConnectedVertexCount(%q) = %d, bfs counts %d", a, got, want)
+			}
+
+			for b := range g.adj {
+				_, want := g.bfs(a, b)[b]
+
+				if got := g.AreConnected(a, b); got != want {
+					t.Errorf("AreConnected(%q, %q) = %v, bfs says %v", a, b, got, want)
+				}
+			}
+		}
+	}
+
+	check()
+	g.RemoveEdge("a", "b")
+	check()
+	g.RemoveVertex("e")
+	check()
+	g.AddEdge("c", "d")
+	check()
+}
+
+func BenchmarkAreConnected(b *testing.B) {
+	g, same, different := benchGraph(b)
+
+	b.Run("indexed", func(b *testing.B) {
+		for b.Loop() {
+			g.AreConnected(same[0], same[1])
+			g.AreConnected(different[0], different[1])
+		}
+	})
+
+	b.Run("bfs", func(b *testing.B) {
+		for b.Loop() {
+			_ = g.bfs(same[0], same[1])[same[1]]
+			_ = g.bfs(different[0], different[1])[different[1]]
+		}
+	})
+}
+
+func BenchmarkConnectedVertexCount(b *testing.B) {
+	g, same, _ := benchGraph(b)
+
+	b.Run("indexed", func(b *testing.B) {
+		for b.Loop() {
+			g.ConnectedVertexCount(same[0])
+		}
+	})
+
+	b.Run("bfs", func(b *testing.B) {
+		for b.Loop() {
+			_ = len(g.bfs(same[0])) - 1
+		}
+	})
+}
+
+func BenchmarkSynonymGroupCount(b *testing.B) {
+	g, _, _ := benchGraph(b)
+
+	b.Run("indexed", func(b *testing.B) {
+		for b.Loop() {
+			g.ConnectivityGroupCount()
+		}
+	})
+
+	b.Run("bfs", func(b *testing.B) {
+		for b.Loop() {
+			bfsGroupCount(g)
+		}
+	})
+}
+
+// BenchmarkRebuildAfterRemoveEdge measures the worst case of the index:
+// every RemoveEdge invalidates it, so the next query rebuilds it.
+func BenchmarkRebuildAfterRemoveEdge(b *testing.B) {
+	g, same, _ := benchGraph(b)
+	neighbor := g.GetNeighbors(same[0])[0]
+
+	b.Run("indexed", func(b *testing.B) {
+		for b.Loop() {
+			g.RemoveEdge(same[0], neighbor)
+			g.AddEdge(same[0], neighbor)
+			g.AreConnected(same[0], same[1])
+		}
+	})
+
+	b.Run("bfs", func(b *testing.B) {
+		for b.Loop() {
+			g.RemoveEdge(same[0], neighbor)
+			g.AddEdge(same[0], neighbor)
+			_ = g.bfs(same[0], same[1])[same[1]]
+		}
+	})
+}
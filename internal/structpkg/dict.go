@@ -1,26 +1,83 @@
 package structpkg
 
 import (
+	"errors"
 	"fmt"
-	"regexp"
+	"io"
+	"slices"
+	"strings"
+	"sync"
+	"synodict-go/internal/common"
 	"synodict-go/internal/stgpkg"
 )
 
-var WordRegex = regexp.MustCompile(`^[\p{L}\s-]+$`)
+// how many similar words a not-found error suggests
+const maxSuggestions = 3
 
+// MaxShortestPaths caps how many paths ExplainSynonymyAll lists.
+const MaxShortestPaths = 100
+
+var relationExistsMessages = map[EdgeType]string{
+	AntonymEdge:  "words \"%s\" and \"%s\" already are antonyms",
+	HypernymEdge: "word \"%[2]s\" already is a broader term of \"%[1]s\"",
+}
+
+var relationMissingMessages = map[EdgeType]string{
+	AntonymEdge:  "words \"%s\" and \"%s\" are not antonyms",
+	HypernymEdge: "word \"%[2]s\" is not a broader term of \"%[1]s\"",
+}
+
+// Dict is safe for concurrent use by multiple goroutines. Queries take a
+// shared lock and run in parallel; mutations, Undo/Redo and the history
+// setters take an exclusive lock. Import and Decode parse the input
+// before locking, so readers only wait for the final swap or merge.
 type Dict struct {
-	graph *Graph
+	graph      *Graph
+	journal    *journal
+	changes    uint64
+	touched    bool
+	changeLog  ChangeLog
+	logged     []graphOp
+	logInvalid bool
+	csv        CsvOptions
+	backups    int
+	policy     common.WordPolicy
+	norm       Normalization
+	mu         sync.RWMutex
+}
+
+type Sense struct {
+	Word  string
+	ID    int
+	Key   string
+	Gloss string
 }
 
 func NewDict() *Dict {
-	return &Dict{graph: NewGraph()}
+	d := &Dict{journal: newJournal(), csv: DefaultCsvOptions, policy: common.DefaultWordPolicy}
+	d.setGraph(NewGraph())
+
+	return d
+}
+
+func describeCall(name string, args ...string) string {
+	var b strings.Builder
+	b.WriteString(name)
+
+	for _, arg := range args {
+		fmt.Fprintf(&b, " %q", arg)
+	}
+
+	return b.String()
 }
 
-func getFormatSerializator(d *Dict, format string) func() []byte {
-	formatHandlers := map[string]func() []byte{
-		"gob":  d.graph.SerializeGob,
-		"csv":  d.graph.SerializeCsv,
-		"csvc": d.graph.SerializeCsvCondensed,
+func getFormatEncoder(d *Dict, format string) func(w io.Writer) error {
+	formatHandlers := map[string]func(w io.Writer) error{
+		"gob":   d.graph.EncodeGob,
+		"csv":   func(w io.Writer) error { return d.graph.EncodeCsv(w, d.csv) },
+		"csvc":  func(w io.Writer) error { return d.graph.EncodeCsvCondensed(w, d.csv) },
+		"json":  d.graph.EncodeJson,
+		"jsonl": d.graph.EncodeJsonLines,
 	}
 
 	handler := formatHandlers[format]
@@ -28,11 +85,15 @@ func getFormatSerializator(d *Dict, format string) func() []byte {
 	return handler
 }
 
-func getFormatDeserializator(format string) func(data []byte) (*Graph, error) {
-	formatHandlers := map[string]func(data []byte) (*Graph, error){
-		"gob":  DeserializeGob,
-		"csv":  DeserializeCsv,
-		"csvc": DeserializeCsvCondensed,
+func getFormatDecoder(d *Dict, format string) func(r io.Reader) (*Graph, error) {
+	opts := d.CsvOptions()
+
+	formatHandlers := map[string]func(r io.Reader) (*Graph, error){
+		"gob":   DecodeGob,
+		"csv":   func(r io.Reader) (*Graph, error) { return DecodeCsv(r, opts) },
+		"csvc":  func(r io.Reader) (*Graph, error) { return DecodeCsvCondensed(r, opts) },
+		"json":  DecodeJson,
+		"jsonl": DecodeJsonLines,
 	}
 
 	handler := formatHandlers[format]
@@ -41,10 +102,10 @@ func getFormatDeserializator(format string) func(data []byte) (*Graph, error) {
 }
 
 func logWordAlreadyExists(d *Dict, word string, log *[]error) bool {
-	if d.WordExists(word) {
+	if d.graph.HasVertex(canonicalSense(word)) {
 		*log = append(
 			*log,
-			fmt.Errorf("dictionary: word \"%s\" already exists", word),
+			newWordError(ErrWordExists, fmt.Sprintf("word \"%s\" already exists", word), word),
 		)
 
 		return false
@@ -54,10 +115,31 @@ func logWordAlreadyExists(d *Dict, word string, log *[]error) bool {
 }
 
 func logWordNotFound(d *Dict, word string, log *[]error) bool {
-	if !d.WordExists(word) {
+	if !d.wordExists(word) {
+		msg := fmt.Sprintf("word \"%s\" does not exist", word)
+		suggestions := d.suggest(word, maxSuggestions)
+
+		if len(suggestions) > 0 {
+			msg += fmt.Sprintf(", did you mean: %s?", strings.Join(suggestions, ", "))
+		}
+
+		err := newWordError(ErrWordNotFound, msg, word)
+		err.Suggestions = suggestions
+		*log = append(*log, err)
+
+		return false
+	}
+
+	return true
+}
+
+func logWordNotMatch(d *Dict, word string, log *[]error) bool {
+	base, _, _ := splitSense(word)
+
+	if err := d.policy.Validate(base); err != nil {
 		*log = append(
 			*log,
-			fmt.Errorf("dictionary: word \"%s\" does not exist", word),
+			newWordError(ErrInvalidWord, fmt.Sprintf("word \"%s\" does not match the conditions: %s", word, err), word),
 		)
 
 		return false
@@ -66,11 +148,11 @@ func logWordNotFound(d *Dict, word string, log *[]error) bool {
 	return true
 }
 
-func logWordNotMatch(word string, log *[]error) bool {
-	if !WordRegex.MatchString(word) {
+func logSenseNotFound(d *Dict, sense string, log *[]error) bool {
+	if !d.graph.HasVertex(sense) {
 		*log = append(
 			*log,
-			fmt.Errorf("dictionary: word \"%s\" does not match the conditions", word),
+			newWordError(ErrWordNotFound, fmt.Sprintf("sense \"%s\" does not exist", sense), sense),
 		)
 
 		return false
@@ -80,10 +162,10 @@ func logWordNotMatch(word string, log *[]error) bool {
 }
 
 func logAlreadyDirectSynonyms(d *Dict, a, b string, log *[]error) bool {
-	if are, _ := d.AreDirectSynonyms(a, b); are {
+	if d.graph.HasEdge(canonicalSense(a), canonicalSense(b)) {
 		*log = append(
 			*log,
-			fmt.Errorf("dictionary: words \"%s\" and \"%s\" already are direct-linked synonyms", a, b),
+			newWordError(ErrAlreadyLinked, fmt.Sprintf("words \"%s\" and \"%s\" already are direct-linked synonyms", a, b), a, b),
 		)
 
 		return false
@@ -93,10 +175,23 @@ func logAlreadyDirectSynonyms(d *Dict, a, b string, log *[]error) bool {
 }
 
 func logWordsNotLinked(d *Dict, a, b string, log *[]error) bool {
-	if are, _ := d.AreDirectSynonyms(a, b); !are {
+	if !d.graph.HasEdge(canonicalSense(a), canonicalSense(b)) {
+		*log = append(
+			*log,
+			newWordError(ErrNotLinked, fmt.Sprintf("words \"%s\" and \"%s\" are not direct-linked synonyms", a, b), a, b),
+		)
+
+		return false
+	}
+
+	return true
+}
+
+func logAlreadyRelated(d *Dict, a, b string, t EdgeType, log *[]error) bool {
+	if d.graph.HasTypedEdge(a, b, t) {
 		*log = append(
 			*log,
-			fmt.Errorf("dicationary: words \"%s\" and \"%s\" are not direct-linked synonyms", a, b),
+			newWordError(ErrAlreadyLinked, fmt.Sprintf(relationExistsMessages[t], a, b), a, b),
 		)
 
 		return false
@@ -105,240 +200,812 @@ func logWordsNotLinked(d *Dict, a, b string, log *[]error) bool {
 	return true
 }
 
-func (d *Dict) AddSynonyms(words ...string) []error {
+func logWordsNotRelated(d *Dict, a, b string, t EdgeType, log *[]error) bool {
+	if !d.graph.HasTypedEdge(a, b, t) {
+		*log = append(
+			*log,
+			newWordError(ErrNotLinked, fmt.Sprintf(relationMissingMessages[t], a, b), a, b),
+		)
+
+		return false
+	}
+
+	return true
+}
+
+func logSameWord(a, b string, log *[]error) bool {
+	if a == b {
+		*log = append(
+			*log,
+			newWordError(ErrSelfRelation, fmt.Sprintf("word \"%s\" cannot be related to itself", a), a),
+		)
+
+		return false
+	}
+
+	return true
+}
+
+func (d *Dict) resolveSenses(word string) []string {
+	base, id, explicit := splitSense(word)
+
+	if !explicit {
+		return d.graph.GetSenses(base)
+	}
+
+	key := senseKey(base, id)
+
+	if !d.graph.HasVertex(key) {
+		return nil
+	}
+
+	return []string{key}
+}
+
+func (d *Dict) collectRelated(word string, related func(sense string) []string) []string {
+	senses := d.resolveSenses(word)
+	seen := make(common.Set)
+	var result []string
+
+	for _, sense := range senses {
+		seen[sense] = common.Void{}
+	}
+
+	for _, sense := range senses {
+		for _, v := range related(sense) {
+			if _, ok := seen[v]; ok {
+				continue
+			}
+
+			seen[v] = common.Void{}
+			result = append(result, v)
+		}
+	}
+
+	common.SortWords(result)
+
+	return d.graph.displayAll(result)
+}
+
+func (d *Dict) addRelation(a, b string, t EdgeType) error {
+	d.begin(describeCall("add "+t.String(), a, b))
+	defer d.commit()
+
+	var errs []error
+	keyA, keyB := canonicalSense(d.key(a)), canonicalSense(d.key(b))
+	ok := logWordNotMatch(d, keyA, &errs) && logWordNotMatch(d, keyB, &errs) &&
+		logSameWord(keyA, keyB, &errs) && logAlreadyRelated(d, keyA, keyB, t, &errs)
+
+	if ok {
+		d.addWord(a)
+		d.addWord(b)
+		err := d.graph.AddTypedEdge(keyA, keyB, t)
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (d *Dict) unlinkRelation(a, b string, t EdgeType) error {
+	d.begin(describeCall("unlink "+t.String(), a, b))
+	defer d.commit()
+
+	var errs []error
+	a, b = canonicalSense(d.key(a)), canonicalSense(d.key(b))
+	ok := logWordNotFound(d, a, &errs) && logWordNotFound(d, b, &errs) && logWordsNotRelated(d, a, b, t, &errs)
+
+	if ok {
+		d.graph.RemoveTypedEdge(a, b, t)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (d *Dict) getRelated(word string, t EdgeType) ([]string, error) {
+	word = d.key(word)
+	var errs []error
+	ok := logWordNotFound(d, word, &errs)
+	var result []string
+
+	if ok {
+		result = d.collectRelated(word, func(sense string) []string {
+			return d.graph.GetTypedNeighbors(sense, t)
+		})
+	}
+
+	return result, errors.Join(errs...)
+}
+
+func (d *Dict) AddSynonyms(words ...string) error {
+	d.begin(describeCall("add", words...))
+	defer d.commit()
+
+	keys := d.keys(words)
 	filtered := []string{}
 	var errs []error
 
-	for i, word := range words {
-		ok := logWordNotMatch(word, &errs)
+	for i, key := range keys {
+		ok := logWordNotMatch(d, key, &errs)
 
-		if i < len(words)-1 {
-			logAlreadyDirectSynonyms(d, word, words[i+1], &errs)
+		if i < len(keys)-1 {
+			logAlreadyDirectSynonyms(d, key, keys[i+1], &errs)
 		}
 
 		if ok {
-			filtered = append(filtered, words[i])
+			d.addWord(words[i])
+			filtered = append(filtered, canonicalSense(key))
 		}
 	}
 
-	switch len(filtered) {
-	case 0:
-		return errs
+	for i := 0; i < len(filtered)-1; i++ {
+		d.graph.AddEdge(filtered[i], filtered[i+1])
+	}
+
+	return errors.Join(errs...)
+}
 
-	case 1:
-		d.AddWords(filtered[0])
+// ValidateWords reports the words AddSynonyms and AddWords would reject,
+// without changing anything.
+func (d *Dict) ValidateWords(words ...string) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
 
-	default:
-		for i := 0; i < len(filtered)-1; i++ {
-			d.graph.AddEdge(filtered[i], filtered[i+1])
-		}
+	var errs []error
+
+	for _, key := range d.keys(words) {
+		logWordNotMatch(d, key, &errs)
 	}
 
-	return errs
+	return errors.Join(errs...)
 }
 
-func (d *Dict) AddWords(words ...string) []error {
+func (d *Dict) AddWords(words ...string) error {
+	d.begin(describeCall("add-words", words...))
+	defer d.commit()
+
 	var errs []error
 
-	for _, word := range words {
-		ok := logWordNotMatch(word, &errs) && logWordAlreadyExists(d, word, &errs)
+	for i, key := range d.keys(words) {
+		ok := logWordNotMatch(d, key, &errs) && logWordAlreadyExists(d, key, &errs)
 
 		if ok {
-			d.graph.AddVertex(word)
+			d.addWord(words[i])
 		}
 	}
 
-	return errs
+	return errors.Join(errs...)
 }
 
-func (d *Dict) RemoveWords(words ...string) []error {
+func (d *Dict) RemoveWords(words ...string) error {
+	d.begin(describeCall("remove", words...))
+	defer d.commit()
+
 	var errs []error
 
-	for _, word := range words {
+	for _, word := range d.keys(words) {
 		ok := logWordNotFound(d, word, &errs)
 
 		if ok {
-			d.graph.RemoveVertex(word)
+			for _, sense := range d.resolveSenses(word) {
+				d.graph.RemoveVertex(sense)
+			}
 		}
 	}
 
-	return errs
+	return errors.Join(errs...)
 }
 
-func (d *Dict) UnlinkSynonyms(a, b string) []error {
+func (d *Dict) UnlinkSynonyms(a, b string) error {
+	d.begin(describeCall("unlink", a, b))
+	defer d.commit()
+
 	var errs []error
+	a, b = canonicalSense(d.key(a)), canonicalSense(d.key(b))
 	ok := logWordNotFound(d, a, &errs) && logWordNotFound(d, b, &errs) && logWordsNotLinked(d, a, b, &errs)
 
 	if ok {
 		d.graph.RemoveEdge(a, b)
 	}
 
-	return errs
+	return errors.Join(errs...)
 }
 
-func (d *Dict) UnlinkSynonymsAndCleanup(a, b string) []error {
+func (d *Dict) UnlinkSynonymsAndCleanup(a, b string) error {
+	d.begin(describeCall("unlink-clean", a, b))
+	defer d.commit()
+
 	var errs []error
+	a, b = canonicalSense(d.key(a)), canonicalSense(d.key(b))
 	ok := logWordNotFound(d, a, &errs) && logWordNotFound(d, b, &errs) && logWordsNotLinked(d, a, b, &errs)
 
 	if ok {
 		d.graph.RemoveEdgeAndCleanup(a, b)
 	}
 
-	return errs
+	return errors.Join(errs...)
+}
+
+func (d *Dict) AddAntonyms(a, b string) error {
+	return d.addRelation(a, b, AntonymEdge)
+}
+
+func (d *Dict) UnlinkAntonyms(a, b string) error {
+	return d.unlinkRelation(a, b, AntonymEdge)
+}
+
+func (d *Dict) AddHypernym(word, broader string) error {
+	return d.addRelation(word, broader, HypernymEdge)
+}
+
+func (d *Dict) UnlinkHypernym(word, broader string) error {
+	return d.unlinkRelation(word, broader, HypernymEdge)
+}
+
+func (d *Dict) GetAntonyms(word string) ([]string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.getRelated(word, AntonymEdge)
+}
+
+func (d *Dict) GetHypernyms(word string) ([]string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.getRelated(word, HypernymEdge)
+}
+
+func (d *Dict) GetHyponyms(word string) ([]string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.getRelated(word, HyponymEdge)
 }
 
 func (d *Dict) GetDirectSynonyms(word string) ([]string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	word = d.key(word)
 	var errs []error
 	ok := logWordNotFound(d, word, &errs)
 	var result []string
 
 	if ok {
-		result = d.graph.GetNeighbors(word)
+		result = d.collectRelated(word, d.graph.GetNeighbors)
 	}
 
-	var err error
-
-	if errs != nil {
-		err = errs[0]
-	}
-
-	return result, err
+	return result, errors.Join(errs...)
 }
 
 func (d *Dict) GetSynomyms(word string) ([]string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	word = d.key(word)
 	var errs []error
 	ok := logWordNotFound(d, word, &errs)
 	var result []string
 
 	if ok {
-		result = d.graph.GetConnectedVertices(word)
+		result = d.collectRelated(word, d.graph.GetConnectedVertices)
 	}
 
-	var err error
-
-	if errs != nil {
-		err = errs[0]
-	}
-
-	return result, err
+	return result, errors.Join(errs...)
 }
 
 func (d *Dict) SynonymCount(word string) (int, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	word = d.key(word)
 	var errs []error
 	ok := logWordNotFound(d, word, &errs)
 	var result int
 
-	if ok {
-		result = d.graph.ConnectedVertexCount(word)
+	if senses := d.resolveSenses(word); ok && len(senses) == 1 {
+		result = d.graph.ConnectedVertexCount(senses[0])
+	} else if ok {
+		result = len(d.collectRelated(word, d.graph.GetConnectedVertices))
 	}
 
-	var err error
+	return result, errors.Join(errs...)
+}
+
+func (d *Dict) matchSenses(a, b string, match func(x, y string) bool) [][2]string {
+	var pairs [][2]string
 
-	if errs != nil {
-		err = errs[0]
+	for _, x := range d.resolveSenses(a) {
+		for _, y := range d.resolveSenses(b) {
+			if match(x, y) {
+				pairs = append(pairs, [2]string{x, y})
+			}
+		}
 	}
 
-	return result, err
+	return pairs
 }
 
-func (d *Dict) AreSynonyms(a, b string) (bool, []error) {
+func (d *Dict) synonymousSenses(a, b string) ([][2]string, error) {
+	a, b = d.key(a), d.key(b)
 	var errs []error
 	ok := logWordNotFound(d, a, &errs) && logWordNotFound(d, b, &errs)
-	var result bool
+	var result [][2]string
 
 	if ok {
-		result = d.graph.AreConnected(a, b)
+		result = d.matchSenses(a, b, d.graph.AreConnected)
+	}
+
+	for i, pair := range result {
+		result[i] = [2]string{d.graph.Display(pair[0]), d.graph.Display(pair[1])}
 	}
 
-	return result, errs
+	return result, errors.Join(errs...)
 }
 
-func (d *Dict) AreDirectSynonyms(a, b string) (bool, []error) {
+func (d *Dict) SynonymousSenses(a, b string) ([][2]string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.synonymousSenses(a, b)
+}
+
+func (d *Dict) AreSynonyms(a, b string) (bool, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	pairs, errs := d.synonymousSenses(a, b)
+
+	return len(pairs) > 0, errs
+}
+
+func (d *Dict) AreDirectSynonyms(a, b string) (bool, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	a, b = d.key(a), d.key(b)
 	var errs []error
 	ok := logWordNotFound(d, a, &errs) && logWordNotFound(d, b, &errs)
 	var result bool
 
 	if ok {
-		result = d.graph.HasEdge(a, b)
+		result = len(d.matchSenses(a, b, d.graph.HasEdge)) > 0
+	}
+
+	return result, errors.Join(errs...)
+}
+
+func (d *Dict) ExplainSynonymy(a, b string) ([]string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	a, b = d.key(a), d.key(b)
+	var errs []error
+	ok := logWordNotFound(d, a, &errs) && logWordNotFound(d, b, &errs)
+	var result []string
+
+	if !ok {
+		return result, errors.Join(errs...)
+	}
+
+	for _, pair := range d.matchSenses(a, b, d.graph.AreConnected) {
+		path := d.graph.ShortestPath(pair[0], pair[1])
+
+		if len(path) > 0 && (len(result) == 0 || len(path) < len(result)) {
+			result = path
+		}
+	}
+
+	return d.graph.displayAll(result), errors.Join(errs...)
+}
+
+// ExplainSynonymyAll returns every shortest path between the senses of a
+// and b, at most MaxShortestPaths of them.
+func (d *Dict) ExplainSynonymyAll(a, b string) ([][]string, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	a, b = d.key(a), d.key(b)
+	var errs []error
+	ok := logWordNotFound(d, a, &errs) && logWordNotFound(d, b, &errs)
+	var result [][]string
+
+	if !ok {
+		return result, errors.Join(errs...)
+	}
+
+	for _, pair := range d.matchSenses(a, b, d.graph.AreConnected) {
+		shortest := d.graph.ShortestPath(pair[0], pair[1])
+
+		switch {
+		case len(shortest) == 0:
+			continue
+
+		case len(result) == 0 || len(shortest) < len(result[0]):
+			result = d.graph.AllShortestPaths(pair[0], pair[1], MaxShortestPaths)
+
+		case len(shortest) == len(result[0]) && len(result) < MaxShortestPaths:
+			paths := d.graph.AllShortestPaths(pair[0], pair[1], MaxShortestPaths-len(result))
+			result = append(result, paths...)
+		}
+	}
+
+	for _, path := range result {
+		d.graph.displayAll(path)
+	}
+
+	return result, errors.Join(errs...)
+}
+
+func (d *Dict) AddSense(word, gloss string) (string, error) {
+	d.begin(describeCall("add-sense", word, gloss))
+	defer d.commit()
+
+	var errs []error
+	spelled, _, _ := splitSense(word)
+	base, _, _ := splitSense(d.key(word))
+	ok := logWordNotMatch(d, base, &errs)
+
+	if !ok {
+		return "", errors.Join(errs...)
+	}
+
+	id := d.graph.NextSenseID(base)
+	key := senseKey(base, id)
+	d.addWord(senseKey(spelled, id))
+	err := d.graph.SetGloss(key, gloss)
+
+	if err != nil {
+		d.graph.RemoveVertex(key)
+		errs = append(errs, err)
+
+		return "", errors.Join(errs...)
+	}
+
+	return d.graph.Display(key), errors.Join(errs...)
+}
+
+func (d *Dict) SetGloss(sense, gloss string) error {
+	d.begin(describeCall("gloss", sense, gloss))
+	defer d.commit()
+
+	var errs []error
+	sense = canonicalSense(d.key(sense))
+	ok := logSenseNotFound(d, sense, &errs)
+
+	if ok {
+		err := d.graph.SetGloss(sense, gloss)
+
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (d *Dict) GetGloss(sense string) string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.graph.GetGloss(canonicalSense(d.key(sense)))
+}
+
+func (d *Dict) GetSenses(word string) ([]Sense, error) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	word = d.key(word)
+	var errs []error
+	ok := logWordNotFound(d, word, &errs)
+	var result []Sense
+
+	if ok {
+		for _, key := range d.resolveSenses(word) {
+			base, id, _ := splitSense(d.graph.Display(key))
+
+			result = append(result, Sense{
+				Word:  base,
+				ID:    id,
+				Key:   d.graph.Display(key),
+				Gloss: d.graph.GetGloss(key),
+			})
+		}
 	}
 
-	return result, errs
+	return result, errors.Join(errs...)
+}
+
+func (s Sense) Ref() string {
+	return fmt.Sprintf("%s#%d", s.Word, s.ID)
+}
+
+func (d *Dict) wordExists(word string) bool {
+	return len(d.resolveSenses(word)) > 0
 }
 
 func (d *Dict) WordExists(word string) bool {
-	return d.graph.HasVertex(word)
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.wordExists(d.key(word))
+}
+
+// suggest returns the display forms of words similar to word.
+func (d *Dict) suggest(word string, limit int) []string {
+	similar := d.graph.SimilarWords(word, limit)
+
+	for i, similarWord := range similar {
+		if senses := d.graph.GetSenses(similarWord); len(senses) > 0 {
+			similar[i], _, _ = splitSense(d.graph.Display(senses[0]))
+		}
+	}
+
+	return similar
+}
+
+// Suggest returns up to limit words spelled like word, closest first, for
+// recovering from typos.
+func (d *Dict) Suggest(word string, limit int) []string {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.suggest(d.key(word), limit)
 }
 
 func (d *Dict) GetWords() []string {
-	return d.graph.GetVertices()
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.graph.displayAll(d.graph.GetVertices())
 }
 
 func (d *Dict) WordCount() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
 	return d.graph.Order()
 }
 
 func (d *Dict) GetSynonymGroups() [][]string {
-	return d.graph.GetConnectivityGroups()
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	groups := d.graph.GetConnectivityGroups()
+
+	for _, group := range groups {
+		d.graph.displayAll(group)
+	}
+
+	return groups
 }
 
 func (d *Dict) SynonymGroupCount() int {
-	return len(d.GetSynonymGroups())
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.graph.ConnectivityGroupCount()
 }
 
 func (d *Dict) Clear() {
-	d.graph = NewGraph()
+	d.begin("clear")
+	defer d.commit()
+
+	d.replaceGraph(NewGraph())
 }
 
 func (d *Dict) Cleanup() {
+	d.begin("cleanup")
+	defer d.commit()
+
 	d.graph.Cleanup()
 }
 
+func (d *Dict) Changes() uint64 {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.changes
+}
+
 func (d *Dict) IsEmpty() bool {
-	return d.WordCount() == 0
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.graph.IsEmpty()
 }
 
-func (d *Dict) Export(path, format string) error {
-	serializator := getFormatSerializator(d, format)
+func (d *Dict) WordPolicy() common.WordPolicy {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.policy
+}
 
-	if serializator == nil {
-		return fmt.Errorf("export failed: format %s is not supported", format)
+// SetWordPolicy only applies to words added from now on; the words already
+// in the dictionary are kept, and imported files are only checked for
+// structure, so a stricter policy never makes saved data unreadable.
+func (d *Dict) SetWordPolicy(policy common.WordPolicy) error {
+	if err := policy.Check(); err != nil {
+		return fmt.Errorf("dictionary: %w", err)
 	}
 
-	data := serializator()
-	addBom := true
+	policy.Scripts = slices.Clone(policy.Scripts)
 
-	if format == "gob" {
-		addBom = false
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.policy = policy
+
+	return nil
+}
+
+func (d *Dict) CsvOptions() CsvOptions {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.csv
+}
+
+func (d *Dict) SetCsvOptions(opts CsvOptions) error {
+	if !slices.Contains(CsvDelimiters, opts.Delimiter) {
+		return fmt.Errorf("dictionary: unsupported csv delimiter %q", opts.Delimiter)
 	}
 
-	err := stgpkg.Write(data, path, addBom)
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.csv = opts
+
+	return nil
+}
+
+func (d *Dict) Backups() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
 
-	return err
+	return d.backups
 }
 
-func (d *Dict) Import(path, format string) error {
-	deserializator := getFormatDeserializator(format)
+// SetBackups keeps the last n versions of every file Export replaces.
+func (d *Dict) SetBackups(n int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.backups = max(n, 0)
+}
+
+func (d *Dict) Encode(w io.Writer, format string) error {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.encode(w, format)
+}
+
+func (d *Dict) encode(w io.Writer, format string) error {
+	encoder := getFormatEncoder(d, format)
 
-	if deserializator == nil {
-		return fmt.Errorf("import failed: format %s is not supported", format)
+	if encoder == nil {
+		return fmt.Errorf("export failed: %w: %s", ErrUnsupportedFormat, format)
 	}
 
-	data, err := stgpkg.Read(path)
+	err := encoder(w)
 
 	if err != nil {
+		return fmt.Errorf("export failed: %w", err)
+	}
+
+	return nil
+}
+
+func (d *Dict) Export(path, format string) error {
+	return d.ExportSnapshot(path, format, nil, nil)
+}
+
+// ExportSnapshot is Export for a snapshot that a change log continues:
+// mark is called while the dictionary is locked for encoding, so nothing
+// is logged between it and the snapshot, and checkpoint gets the checksum
+// of the written file before the file replaces path. If checkpoint fails,
+// path is left untouched.
+func (d *Dict) ExportSnapshot(path, format string, mark func(), checkpoint func(sum []byte) error) error {
+	// checked by name: the encoders close over d.graph, which may only be
+	// read under d.mu
+	if _, ok := common.FormatFileExtensions[format]; !ok {
+		return fmt.Errorf("export failed: %w: %s", ErrUnsupportedFormat, format)
+	}
+
+	addBom := format == "csv" || format == "csvc"
+	file, err := stgpkg.Create(path, addBom, d.Backups())
+
+	if err != nil {
+		return err
+	}
+
+	d.mu.RLock()
+
+	if mark != nil {
+		mark()
+	}
+
+	err = d.encode(file, format)
+	d.mu.RUnlock()
+
+	if err == nil && checkpoint != nil {
+		err = checkpoint(file.Sum())
+	}
+
+	if err != nil {
+		file.Abort()
 		return err
 	}
 
-	graph, err := deserializator(data)
+	return file.Close()
+}
+
+func (d *Dict) Decode(r io.Reader, format, source string) error {
+	graph, err := d.DecodeGraph(r, format)
 
 	if err != nil {
 		return err
 	}
 
-	if d.graph.IsEmpty() {
-		d.graph.FromGraphUnsafe(graph)
+	d.Apply(graph, source, false)
+
+	return nil
+}
+
+// Apply merges a decoded graph into the dictionary, or replaces the
+// dictionary with it when overwrite is set or the dictionary is empty, as a
+// single history entry.
+func (d *Dict) Apply(graph *Graph, source string, overwrite bool) {
+	label := describeCall("import", source)
+
+	if overwrite {
+		label += " --overwrite"
+	}
+
+	d.begin(label)
+	defer d.commit()
+
+	if overwrite || d.graph.IsEmpty() {
+		d.replaceGraph(graph)
 	} else {
-		d.graph.MergeUnsafe(graph)
+		merged := d.graph.Clone()
+		merged.MergeUnsafe(graph)
+		d.replaceGraph(merged)
+	}
+}
+
+// ApplyIfEmpty is Apply into a dictionary that must still be empty; the
+// check and the import are one step, so no change can come in between.
+func (d *Dict) ApplyIfEmpty(graph *Graph, source string) error {
+	d.begin(describeCall("import", source))
+	defer d.commit()
+
+	if !d.graph.IsEmpty() {
+		return fmt.Errorf("import failed: %w", ErrNotEmpty)
 	}
+
+	d.replaceGraph(graph)
+
 	return nil
 }
+
+func (d *Dict) Import(path, format string) error {
+	if getFormatDecoder(d, format) == nil {
+		return fmt.Errorf("import failed: %w: %s", ErrUnsupportedFormat, format)
+	}
+
+	file, err := stgpkg.Open(path)
+
+	if err != nil {
+		return err
+	}
+
+	defer file.Close()
+
+	return d.Decode(file, format, path)
+}
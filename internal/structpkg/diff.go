@@ -0,0 +1,222 @@
+package structpkg
+
+import (
+	"cmp"
+	"slices"
+	"strings"
+	"synodict-go/internal/common"
+)
+
+type Link struct {
+	A    string
+	B    string
+	Type EdgeType
+}
+
+// GroupChange is one synonym group merge or split: the groups in From
+// became the groups in To.
+type GroupChange struct {
+	From [][]string
+	To   [][]string
+}
+
+// GraphDiff describes how to get from one graph to another. Links cover
+// synonym links as well as typed relations; every list is sorted.
+type GraphDiff struct {
+	WordsAdded   []string
+	WordsRemoved []string
+	LinksAdded   []Link
+	LinksRemoved []Link
+	GroupsMerged []GroupChange
+	GroupsSplit  []GroupChange
+}
+
+func (d GraphDiff) IsEmpty() bool {
+	return len(d.WordsAdded) == 0 && len(d.WordsRemoved) == 0 &&
+		len(d.LinksAdded) == 0 && len(d.LinksRemoved) == 0 &&
+		len(d.GroupsMerged) == 0 && len(d.GroupsSplit) == 0
+}
+
+func compareLinks(x, y Link) int {
+	return cmp.Or(cmp.Compare(x.Type, y.Type), common.CompareWords(x.A, y.A), common.CompareWords(x.B, y.B))
+}
+
+func (g *Graph) links() []Link {
+	var links []Link
+
+	for vertex, neighbors := range g.adj {
+		for neighbor := range neighbors {
+			if common.CompareWords(vertex, neighbor) < 0 {
+				links = append(links, Link{A: vertex, B: neighbor, Type: SynonymEdge})
+			}
+		}
+	}
+
+	for vertex, antonyms := range g.antonyms {
+		for antonym := range antonyms {
+			if common.CompareWords(vertex, antonym) < 0 {
+				links = append(links, Link{A: vertex, B: antonym, Type: AntonymEdge})
+			}
+		}
+	}
+
+	for vertex, broader := range g.broader {
+		for b := range broader {
+			links = append(links, Link{A: vertex, B: b, Type: HypernymEdge})
+		}
+	}
+
+	return links
+}
+
+func (g *Graph) hasLink(link Link) bool {
+	if link.Type == SynonymEdge {
+		return g.HasEdge(link.A, link.B)
+	}
+
+	return g.HasTypedEdge(link.A, link.B, link.Type)
+}
+
+func missingLinks(from, in *Graph) []Link {
+	missing := []Link{}
+
+	for _, link := range from.links() {
+		if !in.hasLink(link) {
+			missing = append(missing, link)
+		}
+	}
+
+	slices.SortFunc(missing, compareLinks)
+
+	return missing
+}
+
+func missingVertices(from, in *Graph) []string {
+	missing := []string{}
+
+	for vertex := range from.adj {
+		if !in.HasVertex(vertex) {
+			missing = append(missing, vertex)
+		}
+	}
+
+	common.SortWords(missing)
+
+	return missing
+}
+
+func sortedGroups(g *Graph) ([][]string, map[string]int) {
+	groups := g.GetConnectivityGroups()
+	index := make(map[string]int)
+
+	for i, group := range groups {
+		for _, vertex := range group {
+			index[vertex] = i
+		}
+	}
+
+	return groups, index
+}
+
+// groupChanges finds every group of to that is made of words from several
+// groups of from.
+func groupChanges(from, to [][]string, fromIndex map[string]int) []GroupChange {
+	changes := []GroupChange{}
+
+	for _, group := range to {
+		parts := make(common.Set)
+
+		for _, vertex := range group {
+			if i, ok := fromIndex[vertex]; ok {
+				parts[strings.Join(from[i], "\x00")] = common.Void{}
+			}
+		}
+
+		if len(parts) < 2 {
+			continue
+		}
+
+		change := GroupChange{To: [][]string{group}}
+
+		for part := range parts {
+			change.From = append(change.From, strings.Split(part, "\x00"))
+		}
+
+		common.SortGroups(change.From)
+		changes = append(changes, change)
+	}
+
+	return changes
+}
+
+// Diff reports what changed from g to other.
+func (g *Graph) Diff(other *Graph) GraphDiff {
+	diff := GraphDiff{
+		WordsAdded:   missingVertices(other, g),
+		WordsRemoved: missingVertices(g, other),
+		LinksAdded:   missingLinks(other, g),
+		LinksRemoved: missingLinks(g, other),
+	}
+
+	oldGroups, oldIndex := sortedGroups(g)
+	newGroups, newIndex := sortedGroups(other)
+
+	diff.GroupsMerged = groupChanges(oldGroups, newGroups, oldIndex)
+	diff.GroupsSplit = groupChanges(newGroups, oldGroups, newIndex)
+
+	for i := range diff.GroupsSplit {
+		split := &diff.GroupsSplit[i]
+		split.From, split.To = split.To, split.From
+	}
+
+	diff.display(g, other)
+
+	return diff
+}
+
+// display replaces the words of the diff, which are keys, with the way
+// each graph displays them.
+func (d *GraphDiff) display(from, to *Graph) {
+	from.displayAll(d.WordsRemoved)
+	to.displayAll(d.WordsAdded)
+
+	for _, links := range []struct {
+		graph *Graph
+		links []Link
+	}{{to, d.LinksAdded}, {from, d.LinksRemoved}} {
+		for i := range links.links {
+			link := &links.links[i]
+			link.A, link.B = links.graph.Display(link.A), links.graph.Display(link.B)
+		}
+	}
+
+	for _, changes := range [][]GroupChange{d.GroupsMerged, d.GroupsSplit} {
+		for i := range changes {
+			changes[i].From = displayGroups(from, changes[i].From)
+			changes[i].To = displayGroups(to, changes[i].To)
+		}
+	}
+}
+
+func displayGroups(g *Graph, groups [][]string) [][]string {
+	displayed := make([][]string, len(groups))
+
+	for i, group := range groups {
+		displayed[i] = g.displayAll(slices.Clone(group))
+	}
+
+	return displayed
+}
+
+// Diff copies other under its own lock before locking d, so two Diffs in
+// opposite directions never wait for each other.
+func (d *Dict) Diff(other *Dict) GraphDiff {
+	other.mu.RLock()
+	theirs := other.graph.Clone()
+	other.mu.RUnlock()
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.graph.Diff(theirs)
+}
@@ -0,0 +1,221 @@
+package structpkg
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+)
+
+var (
+	ErrWordNotFound      = errors.New("word not found")
+	ErrWordExists        = errors.New("word already exists")
+	ErrInvalidWord       = errors.New("invalid word")
+	ErrSelfRelation      = errors.New("word related to itself")
+	ErrNotLinked         = errors.New("words not linked")
+	ErrAlreadyLinked     = errors.New("words already linked")
+	ErrUnsupportedFormat = errors.New("unsupported format")
+	ErrNotEmpty          = errors.New("dictionary not empty")
+)
+
+type WordError struct {
+	Err         error
+	Words       []string
+	Suggestions []string
+	msg         string
+}
+
+func newWordError(err error, msg string, words ...string) *WordError {
+	return &WordError{Err: err, Words: words, msg: msg}
+}
+
+func (e *WordError) Error() string {
+	return "dictionary: " + e.msg
+}
+
+func (e *WordError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorInfo is an error broken down for machine-readable output. Code is
+// one of the codes in errorCodes, "validation_failed", "file_not_found" or
+// "error" for anything else.
+type ErrorInfo struct {
+	Code        string   `json:"code"`
+	Message     string   `json:"message"`
+	Words       []string `json:"words,omitempty"`
+	Suggestions []string `json:"suggestions,omitempty"`
+	Line        int      `json:"line,omitempty"`
+	Column      int      `json:"column,omitempty"`
+}
+
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{ErrWordNotFound, "word_not_found"},
+	{ErrWordExists, "word_exists"},
+	{ErrInvalidWord, "invalid_word"},
+	{ErrSelfRelation, "self_relation"},
+	{ErrNotLinked, "not_linked"},
+	{ErrAlreadyLinked, "already_linked"},
+	{ErrUnsupportedFormat, "unsupported_format"},
+	{ErrNotEmpty, "not_empty"},
+}
+
+func DescribeError(err error) ErrorInfo {
+	info := ErrorInfo{Code: "error", Message: err.Error()}
+
+	var wordErr *WordError
+	var validationErr *ValidationError
+
+	switch {
+	case errors.As(err, &wordErr):
+		info.Words = wordErr.Words
+		info.Suggestions = wordErr.Suggestions
+
+	case errors.As(err, &validationErr):
+		info.Code = "validation_failed"
+		info.Line, info.Column = validationErr.Line, validationErr.Column
+
+	case errors.Is(err, fs.ErrNotExist):
+		info.Code = "file_not_found"
+	}
+
+	for _, known := range errorCodes {
+		if errors.Is(err, known.err) {
+			info.Code = known.code
+			break
+		}
+	}
+
+	return info
+}
+
+type ValidationError struct {
+	Line   int
+	Column int
+	Msg    string
+	Err    error
+}
+
+func validationErrorf(format string, args ...any) *ValidationError {
+	return &ValidationError{Msg: fmt.Sprintf(format, args...)}
+}
+
+func (e *ValidationError) Error() string {
+	switch {
+	case e.Line > 0 && e.Column > 0:
+		return fmt.Sprintf("graph validation failed: line %d, column %d: %s", e.Line, e.Column, e.Msg)
+
+	case e.Line > 0:
+		return fmt.Sprintf("graph validation failed: line %d: %s", e.Line, e.Msg)
+	}
+
+	return "graph validation failed: " + e.Msg
+}
+
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+func atPosition(err error, line, column int) error {
+	if err == nil {
+		return nil
+	}
+
+	var validationErr *ValidationError
+
+	if errors.As(err, &validationErr) {
+		positioned := *validationErr
+
+		if positioned.Line == 0 {
+			positioned.Line, positioned.Column = line, column
+		}
+
+		return &positioned
+	}
+
+	return &ValidationError{Line: line, Column: column, Msg: err.Error(), Err: err}
+}
+
+func advancePosition(line, column int, data []byte) (int, int) {
+	for _, c := range data {
+		if c == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+
+	return line, column
+}
+
+func offsetPosition(data []byte, offset int64) (int, int) {
+	offset = min(max(offset, 0), int64(len(data)))
+
+	return advancePosition(1, 1, data[:offset])
+}
+
+const positionWindow = 1 << 20
+
+// positionReader remembers at least the last positionWindow bytes it has
+// passed through, so a decoder offset can be turned into a line and column without
+// keeping the whole input around.
+type positionReader struct {
+	r      io.Reader
+	window []byte
+	base   int64
+	line   int
+	column int
+}
+
+func newPositionReader(r io.Reader) *positionReader {
+	return &positionReader{r: r, line: 1, column: 1}
+}
+
+func (p *positionReader) Read(buf []byte) (int, error) {
+	n, err := p.r.Read(buf)
+	p.window = append(p.window, buf[:n]...)
+
+	if len(p.window) > 2*positionWindow {
+		excess := len(p.window) - positionWindow
+		p.line, p.column = advancePosition(p.line, p.column, p.window[:excess])
+		p.base += int64(excess)
+		p.window = append(p.window[:0], p.window[excess:]...)
+	}
+
+	return n, err
+}
+
+func (p *positionReader) position(offset int64) (int, int) {
+	if offset < p.base {
+		return 0, 0
+	}
+
+	offset = min(offset-p.base, int64(len(p.window)))
+
+	return advancePosition(p.line, p.column, p.window[:offset])
+}
+
+func jsonError(data []byte, err error) *ValidationError {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	offset := int64(-1)
+
+	if errors.As(err, &syntaxErr) {
+		offset = syntaxErr.Offset
+	} else if errors.As(err, &typeErr) {
+		offset = typeErr.Offset
+	}
+
+	if offset < 0 {
+		return &ValidationError{Msg: err.Error(), Err: err}
+	}
+
+	line, column := offsetPosition(data, offset)
+
+	return &ValidationError{Line: line, Column: column, Msg: err.Error(), Err: err}
+}
@@ -0,0 +1,69 @@
+package structpkg
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+)
+
+func TestDescribeError(t *testing.T) {
+	d := NewDict()
+
+	if err := d.AddSynonyms("word", "ward"); err != nil {
+		t.Fatal(err)
+	}
+
+	_, notFound := d.GetSynomyms("wrod")
+	notLinked := d.UnlinkSynonyms("word", "wrod")
+	_, openErr := os.Open("does-not-exist.json")
+
+	tests := []struct {
+		name string
+		err  error
+		want ErrorInfo
+	}{
+		{
+			name: "word not found",
+			err:  notFound,
+			want: ErrorInfo{Code: "word_not_found", Words: []string{"wrod"}, Suggestions: []string{"word", "ward"}},
+		},
+		{
+			name: "joined",
+			err:  notLinked,
+			want: ErrorInfo{Code: "word_not_found", Words: []string{"wrod"}, Suggestions: []string{"word", "ward"}},
+		},
+		{
+			name: "validation",
+			err:  atPosition(validationErrorf("bad"), 3, 4),
+			want: ErrorInfo{Code: "validation_failed", Line: 3, Column: 4},
+		},
+		{
+			name: "wrapped format",
+			err:  fmt.Errorf("import failed: %w: xml", ErrUnsupportedFormat),
+			want: ErrorInfo{Code: "unsupported_format"},
+		},
+		{
+			name: "missing file",
+			err:  fmt.Errorf("file read failed: %w", openErr),
+			want: ErrorInfo{Code: "file_not_found"},
+		},
+		{
+			name: "anything else",
+			err:  errors.New("boom"),
+			want: ErrorInfo{Code: "error"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := DescribeError(tt.err)
+			tt.want.Message = tt.err.Error()
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DescribeError() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,971 @@
+package structpkg
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"synodict-go/internal/common"
+)
+
+const maxLineSize = 64 << 20
+
+// Gob files start with a graphDTO that holds only the version and the
+// number of entries, followed by one gobEntry per set or gloss, so neither
+// side holds the whole file in memory. Files of earlier versions hold a
+// single graphDTO with everything in it, in the maps or in the lists.
+const gobVersion = 1
+
+type graphDTO struct {
+	Version     int
+	Entries     int
+	Adj         map[string]common.Set
+	Antonyms    map[string]common.Set
+	Broader     map[string]common.Set
+	Glosses     map[string]string
+	AdjList     []setDTO
+	AntonymList []setDTO
+	BroaderList []setDTO
+	GlossList   []glossDTO
+}
+
+type setDTO struct {
+	Key    string
+	Values []string
+}
+
+type glossDTO struct {
+	Word  string
+	Gloss string
+}
+
+const (
+	adjEntry uint8 = iota
+	antonymEntry
+	broaderEntry
+	glossEntry
+)
+
+type gobEntry struct {
+	Kind   uint8
+	Key    string
+	Values []string
+	Gloss  string
+}
+
+type jsonLineDTO struct {
+	Word  string `json:"word,omitempty"`
+	Gloss string `json:"gloss,omitempty"`
+	A     string `json:"a,omitempty"`
+	B     string `json:"b,omitempty"`
+	Type  string `json:"type,omitempty"`
+}
+
+var csvSections = map[string]EdgeType{
+	"#antonyms":  AntonymEdge,
+	"#hypernyms": HypernymEdge,
+}
+
+// CsvOptions configures how the csv and csvc formats are written. Reading
+// detects the delimiter and skips the header row on its own, so files
+// written with any options, or by earlier versions, can be imported.
+type CsvOptions struct {
+	Delimiter rune
+	Header    bool
+}
+
+var DefaultCsvOptions = CsvOptions{Delimiter: ';'}
+
+var CsvDelimiters = []rune{';', ',', '\t'}
+
+// header rows start with "#", which no vertex can, so they are never
+// mistaken for data
+var csvHeader = []string{"#word", "synonyms"}
+var csvCondensedHeader = []string{"#word", "word", "relation"}
+
+const csvSniffSize = 64 * 1024
+
+const glossesSection = "#glosses"
+const glossMarker = "gloss"
+
+func newLineScanner(r io.Reader) *bufio.Scanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineSize)
+
+	return scanner
+}
+
+func scanError(err error) error {
+	return &ValidationError{Msg: err.Error(), Err: err}
+}
+
+// sortedDisplay lists the set in order, as the vertices are displayed.
+func (g *Graph) sortedDisplay(set common.Set) []string {
+	return g.displayAll(common.SortedKeys(set))
+}
+
+func setsFromDTO(list []setDTO) map[string]common.Set {
+	sets := make(map[string]common.Set, len(list))
+
+	for _, entry := range list {
+		sets[entry.Key] = make(common.Set, len(entry.Values))
+
+		for _, value := range entry.Values {
+			sets[entry.Key][value] = common.Void{}
+		}
+	}
+
+	return sets
+}
+
+// gob
+func (g *Graph) EncodeGob(w io.Writer) error {
+	enc := gob.NewEncoder(w)
+	header := graphDTO{
+		Version: gobVersion,
+		Entries: len(g.adj) + len(g.antonyms) + len(g.broader) + len(g.glosses),
+	}
+
+	if err := enc.Encode(&header); err != nil {
+		return err
+	}
+
+	for _, section := range []struct {
+		kind uint8
+		sets map[string]common.Set
+	}{
+		{adjEntry, g.adj},
+		{antonymEntry, g.antonyms},
+		{broaderEntry, g.broader},
+	} {
+		for _, key := range common.SortedKeys(section.sets) {
+			entry := gobEntry{Kind: section.kind, Key: g.Display(key), Values: g.sortedDisplay(section.sets[key])}
+
+			if err := enc.Encode(&entry); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, word := range common.SortedKeys(g.glosses) {
+		entry := gobEntry{Kind: glossEntry, Key: g.Display(word), Gloss: g.glosses[word]}
+
+		if err := enc.Encode(&entry); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func DecodeGob(r io.Reader) (*Graph, error) {
+	dec := gob.NewDecoder(r)
+	var dto graphDTO
+	err := dec.Decode(&dto)
+
+	if errors.Is(err, io.EOF) {
+		return NewGraph(), nil
+	}
+
+	if err != nil {
+		return nil, &ValidationError{Msg: err.Error(), Err: err}
+	}
+
+	var graph *Graph
+
+	switch {
+	case dto.Version == 0:
+		graph = graphFromDTO(&dto)
+
+	case dto.Version == gobVersion:
+		graph, err = decodeGobEntries(dec, dto.Entries)
+
+		if err != nil {
+			return nil, err
+		}
+
+	default:
+		return nil, &ValidationError{Msg: fmt.Sprintf("unknown gob version %d", dto.Version)}
+	}
+
+	graph.narrower = reverseSets(graph.broader)
+	graph.invalidateIndex()
+
+	err = validateGraph(graph)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return graph, nil
+}
+
+func decodeGobEntries(dec *gob.Decoder, count int) (*Graph, error) {
+	graph := NewGraph()
+
+	for range count {
+		var entry gobEntry
+
+		if err := dec.Decode(&entry); err != nil {
+			if errors.Is(err, io.EOF) {
+				err = io.ErrUnexpectedEOF
+			}
+
+			return nil, &ValidationError{Msg: err.Error(), Err: err}
+		}
+
+		var sets map[string]common.Set
+
+		switch entry.Kind {
+		case adjEntry:
+			sets = graph.adj
+
+		case antonymEntry:
+			sets = graph.antonyms
+
+		case broaderEntry:
+			sets = graph.broader
+
+		case glossEntry:
+			graph.glosses[entry.Key] = entry.Gloss
+			continue
+
+		default:
+			return nil, &ValidationError{Msg: fmt.Sprintf("unknown gob entry kind %d", entry.Kind)}
+		}
+
+		set := make(common.Set, len(entry.Values))
+
+		for _, value := range entry.Values {
+			set[value] = common.Void{}
+		}
+
+		sets[entry.Key] = set
+	}
+
+	return graph, nil
+}
+
+// graphFromDTO reads the single graphDTO of files written by earlier
+// versions.
+func graphFromDTO(dto *graphDTO) *Graph {
+	if dto.AdjList != nil {
+		dto.Adj = setsFromDTO(dto.AdjList)
+		dto.Antonyms = setsFromDTO(dto.AntonymList)
+		dto.Broader = setsFromDTO(dto.BroaderList)
+		dto.Glosses = make(map[string]string, len(dto.GlossList))
+
+		for _, entry := range dto.GlossList {
+			dto.Glosses[entry.Word] = entry.Gloss
+		}
+	}
+
+	graph := NewGraph()
+
+	if dto.Adj != nil {
+		graph.adj = dto.Adj
+	}
+
+	if dto.Antonyms != nil {
+		graph.antonyms = dto.Antonyms
+	}
+
+	if dto.Broader != nil {
+		graph.broader = dto.Broader
+	}
+
+	if dto.Glosses != nil {
+		graph.glosses = dto.Glosses
+	}
+
+	return graph
+}
+
+// csv
+func newCsvWriter(w io.Writer, opts CsvOptions) *csv.Writer {
+	cw := csv.NewWriter(w)
+	cw.Comma = opts.Delimiter
+
+	return cw
+}
+
+// sniffDelimiter takes the delimiter from the header row if there is one,
+// and otherwise picks the one of the first record that has any outside
+// quotes, so files written with other options (or by earlier versions,
+// which always used ";") are read correctly. Ties go to preferred.
+func sniffDelimiter(head []byte, preferred rune) rune {
+	if rest, ok := bytes.CutPrefix(head, []byte(csvHeader[0])); ok && len(rest) > 0 {
+		if r := rune(rest[0]); slices.Contains(CsvDelimiters, r) {
+			return r
+		}
+	}
+
+	counts := make(map[rune]int)
+	inQuotes := false
+
+	for _, r := range string(head) {
+		switch {
+		case r == '"':
+			inQuotes = !inQuotes
+
+		case r == '\n' && !inQuotes:
+			if len(counts) > 0 {
+				return pickDelimiter(counts, preferred)
+			}
+
+		case !inQuotes && slices.Contains(CsvDelimiters, r):
+			counts[r]++
+		}
+	}
+
+	if len(counts) > 0 {
+		return pickDelimiter(counts, preferred)
+	}
+
+	return preferred
+}
+
+func pickDelimiter(counts map[rune]int, preferred rune) rune {
+	best := preferred
+
+	for _, delimiter := range CsvDelimiters {
+		if counts[delimiter] > counts[best] {
+			best = delimiter
+		}
+	}
+
+	return best
+}
+
+func newCsvReader(r io.Reader, opts CsvOptions) *csv.Reader {
+	br := bufio.NewReaderSize(r, csvSniffSize)
+	head, _ := br.Peek(csvSniffSize)
+
+	cr := csv.NewReader(br)
+	cr.Comma = sniffDelimiter(head, opts.Delimiter)
+	cr.FieldsPerRecord = -1
+	cr.ReuseRecord = true
+
+	return cr
+}
+
+// readCsvRecord returns the next record that is not blank, skipping the
+// header row, along with its line number.
+func readCsvRecord(cr *csv.Reader, first *bool) ([]string, int, error) {
+	for {
+		record, err := cr.Read()
+
+		if err != nil {
+			var parseErr *csv.ParseError
+
+			if errors.As(err, &parseErr) {
+				return nil, 0, &ValidationError{Line: parseErr.Line, Column: parseErr.Column, Msg: parseErr.Err.Error(), Err: err}
+			}
+
+			if errors.Is(err, io.EOF) {
+				return nil, 0, err
+			}
+
+			return nil, 0, scanError(err)
+		}
+
+		line, _ := cr.FieldPos(0)
+		isHeader := *first && strings.HasPrefix(record[0], "#") && len(record) > 1
+		*first = false
+
+		if isHeader || len(record) == 1 && strings.TrimSpace(record[0]) == "" {
+			continue
+		}
+
+		return record, line, nil
+	}
+}
+
+func csvPosition(cr *csv.Reader, field int, err error) error {
+	line, column := cr.FieldPos(field)
+
+	return atPosition(err, line, column)
+}
+
+func (g *Graph) writeCsvLines(w *csv.Writer, sets map[string]common.Set) {
+	for _, vertex := range common.SortedKeys(sets) {
+		w.Write(append([]string{g.Display(vertex)}, g.sortedDisplay(sets[vertex])...))
+	}
+}
+
+func (g *Graph) EncodeCsv(w io.Writer, opts CsvOptions) error {
+	cw := newCsvWriter(w, opts)
+
+	if opts.Header {
+		cw.Write(csvHeader)
+	}
+
+	g.writeCsvLines(cw, g.adj)
+
+	for _, section := range []string{"#antonyms", "#hypernyms"} {
+		sets, _ := g.typedSets(csvSections[section])
+
+		if len(sets) == 0 {
+			continue
+		}
+
+		cw.Write([]string{section})
+		g.writeCsvLines(cw, sets)
+	}
+
+	if len(g.glosses) > 0 {
+		cw.Write([]string{glossesSection})
+
+		for _, vertex := range common.SortedKeys(g.glosses) {
+			cw.Write([]string{g.Display(vertex), g.glosses[vertex]})
+		}
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+func DecodeCsv(r io.Reader, opts CsvOptions) (*Graph, error) {
+	g := NewGraph()
+	cr := newCsvReader(r, opts)
+
+	section := SynonymEdge
+	sets := g.adj
+	inGlosses := false
+	first := true
+
+	for {
+		record, lineNo, err := readCsvRecord(cr, &first)
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		if len(record) == 1 && record[0] == glossesSection {
+			inGlosses = true
+			continue
+		}
+
+		if inGlosses {
+			if len(record) != 2 {
+				return nil, &ValidationError{Line: lineNo, Msg: fmt.Sprintf("invalid gloss record %q", strings.Join(record, string(cr.Comma)))}
+			}
+
+			g.glosses[record[0]] = record[1]
+
+			continue
+		}
+
+		if t, ok := csvSections[record[0]]; ok && len(record) == 1 {
+			section = t
+			sets, _ = g.typedSets(t)
+
+			continue
+		}
+
+		if _, ok := sets[record[0]]; ok {
+			return nil, csvPosition(cr, 0, validationErrorf("duplicate vertex %q found in %s section", record[0], section))
+		}
+
+		sets[record[0]] = make(common.Set)
+
+		for _, vertex := range record[1:] {
+			sets[record[0]][vertex] = common.Void{}
+		}
+	}
+
+	g.narrower = reverseSets(g.broader)
+	g.invalidateIndex()
+
+	err := validateGraph(g)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// csv condensed
+func (g *Graph) EncodeCsvCondensed(w io.Writer, opts CsvOptions) error {
+	cw := newCsvWriter(w, opts)
+
+	if opts.Header {
+		cw.Write(csvCondensedHeader)
+	}
+
+	for _, vertex := range common.SortedKeys(g.adj) {
+		neighbors := g.adj[vertex]
+
+		if len(neighbors) == 0 && !g.hasTypedEdges(vertex) {
+			cw.Write([]string{g.Display(vertex)})
+
+			continue
+		}
+
+		for _, neighbor := range common.SortedKeys(neighbors) {
+			if common.CompareWords(vertex, neighbor) < 0 {
+				cw.Write([]string{g.Display(vertex), g.Display(neighbor)})
+			}
+		}
+	}
+
+	for _, vertex := range common.SortedKeys(g.antonyms) {
+		for _, antonym := range common.SortedKeys(g.antonyms[vertex]) {
+			if common.CompareWords(vertex, antonym) < 0 {
+				cw.Write([]string{g.Display(vertex), g.Display(antonym), AntonymEdge.String()})
+			}
+		}
+	}
+
+	for _, vertex := range common.SortedKeys(g.broader) {
+		for _, b := range common.SortedKeys(g.broader[vertex]) {
+			cw.Write([]string{g.Display(vertex), g.Display(b), HypernymEdge.String()})
+		}
+	}
+
+	for _, vertex := range common.SortedKeys(g.glosses) {
+		cw.Write([]string{g.Display(vertex), g.glosses[vertex], glossMarker})
+	}
+
+	cw.Flush()
+
+	return cw.Error()
+}
+
+func DecodeCsvCondensed(r io.Reader, opts CsvOptions) (*Graph, error) {
+	g := NewGraph()
+	cr := newCsvReader(r, opts)
+	first := true
+
+	for {
+		record, _, err := readCsvRecord(cr, &first)
+
+		if errors.Is(err, io.EOF) {
+			break
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		field := 0
+
+		switch len(record) {
+		case 1:
+			err = g.AddVertex(record[0])
+
+		case 2:
+			err = g.AddEdge(record[0], record[1])
+
+		case 3:
+			if record[2] == glossMarker {
+				err = g.AddVertex(record[0])
+
+				if err == nil {
+					err = g.SetGloss(record[0], record[1])
+				}
+
+				break
+			}
+
+			var t EdgeType
+			t, err = ParseEdgeType(record[2])
+
+			if err != nil {
+				field = 2
+				break
+			}
+
+			err = g.AddTypedEdge(record[0], record[1], t)
+
+		default:
+			err = validationErrorf("invalid number of fields in record %q", strings.Join(record, string(cr.Comma)))
+		}
+
+		if err != nil {
+			return nil, csvPosition(cr, field, err)
+		}
+	}
+
+	return g, nil
+}
+
+// json
+func writeJsonSection[V any](w *bufio.Writer, g *Graph, name string, entries map[string]V, value func(key string) any, first bool) {
+	if !first {
+		w.WriteString(",\n")
+	}
+
+	fmt.Fprintf(w, "  %q: {", name)
+
+	for i, key := range common.SortedKeys(entries) {
+		if i > 0 {
+			w.WriteByte(',')
+		}
+
+		k, _ := json.Marshal(g.Display(key))
+		v, _ := json.Marshal(value(key))
+		fmt.Fprintf(w, "\n    %s: %s", k, v)
+	}
+
+	if len(entries) > 0 {
+		w.WriteString("\n  ")
+	}
+
+	w.WriteByte('}')
+}
+
+func (g *Graph) EncodeJson(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	bw.WriteString("{\n")
+
+	writeJsonSection(bw, g, "synonyms", g.adj, func(vertex string) any {
+		return g.sortedDisplay(g.adj[vertex])
+	}, true)
+
+	if len(g.antonyms) > 0 {
+		writeJsonSection(bw, g, "antonyms", g.antonyms, func(vertex string) any {
+			return g.sortedDisplay(g.antonyms[vertex])
+		}, false)
+	}
+
+	if len(g.broader) > 0 {
+		writeJsonSection(bw, g, "hypernyms", g.broader, func(vertex string) any {
+			return g.sortedDisplay(g.broader[vertex])
+		}, false)
+	}
+
+	if len(g.glosses) > 0 {
+		writeJsonSection(bw, g, "glosses", g.glosses, func(vertex string) any {
+			return g.glosses[vertex]
+		}, false)
+	}
+
+	bw.WriteString("\n}\n")
+
+	return bw.Flush()
+}
+
+type jsonStream struct {
+	dec    *json.Decoder
+	reader *positionReader
+}
+
+func (s *jsonStream) fail(err error, base int64) error {
+	var syntaxErr *json.SyntaxError
+	var typeErr *json.UnmarshalTypeError
+	offset := s.dec.InputOffset()
+
+	if errors.As(err, &syntaxErr) {
+		offset = syntaxErr.Offset
+	} else if errors.As(err, &typeErr) {
+		offset = base + typeErr.Offset
+	}
+
+	line, column := s.reader.position(offset)
+
+	return atPosition(err, line, column)
+}
+
+func (s *jsonStream) expect(delim json.Delim) error {
+	tok, err := s.dec.Token()
+
+	if err != nil {
+		return s.fail(err, 0)
+	}
+
+	if tok != delim {
+		return s.fail(fmt.Errorf("expected \"%v\", got %v", delim, tok), 0)
+	}
+
+	return nil
+}
+
+func (s *jsonStream) decode(v any) error {
+	base := s.dec.InputOffset()
+	err := s.dec.Decode(v)
+
+	if err != nil {
+		return s.fail(err, base)
+	}
+
+	return nil
+}
+
+func (s *jsonStream) object(entry func(key string) error) error {
+	if err := s.expect('{'); err != nil {
+		return err
+	}
+
+	for s.dec.More() {
+		tok, err := s.dec.Token()
+
+		if err != nil {
+			return s.fail(err, 0)
+		}
+
+		if err := entry(tok.(string)); err != nil {
+			return s.fail(err, 0)
+		}
+	}
+
+	return s.expect('}')
+}
+
+func (s *jsonStream) array(item func() error) error {
+	if err := s.expect('['); err != nil {
+		return err
+	}
+
+	for s.dec.More() {
+		if err := item(); err != nil {
+			return s.fail(err, 0)
+		}
+	}
+
+	return s.expect(']')
+}
+
+func (s *jsonStream) edges(g *Graph, t EdgeType) error {
+	return s.object(func(vertex string) error {
+		var targets []string
+
+		if err := s.decode(&targets); err != nil {
+			return err
+		}
+
+		if err := g.AddVertex(vertex); err != nil {
+			return err
+		}
+
+		for _, target := range targets {
+			if err := g.AddTypedEdge(vertex, target, t); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+func DecodeJson(r io.Reader) (*Graph, error) {
+	g := NewGraph()
+	reader := newPositionReader(r)
+	stream := &jsonStream{dec: json.NewDecoder(reader), reader: reader}
+	glosses := make(map[string]string)
+
+	tok, err := stream.dec.Token()
+
+	if errors.Is(err, io.EOF) {
+		return g, nil
+	}
+
+	if err != nil {
+		return nil, stream.fail(err, 0)
+	}
+
+	if tok != json.Delim('{') {
+		return nil, stream.fail(fmt.Errorf("expected \"{\", got %v", tok), 0)
+	}
+
+	for stream.dec.More() {
+		tok, err := stream.dec.Token()
+
+		if err != nil {
+			return nil, stream.fail(err, 0)
+		}
+
+		switch tok {
+		case "synonyms":
+			err = stream.edges(g, SynonymEdge)
+
+		case "antonyms":
+			err = stream.edges(g, AntonymEdge)
+
+		case "hypernyms":
+			err = stream.edges(g, HypernymEdge)
+
+		case "groups":
+			err = stream.array(func() error {
+				var group []string
+
+				if err := stream.decode(&group); err != nil {
+					return err
+				}
+
+				for i, vertex := range group {
+					var err error
+
+					if i == 0 {
+						err = g.AddVertex(vertex)
+					} else {
+						err = g.AddEdge(group[i-1], vertex)
+					}
+
+					if err != nil {
+						return err
+					}
+				}
+
+				return nil
+			})
+
+		case "glosses":
+			err = stream.object(func(vertex string) error {
+				var gloss string
+				err := stream.decode(&gloss)
+				glosses[vertex] = gloss
+
+				return err
+			})
+
+		default:
+			err = stream.fail(fmt.Errorf("unknown field %v", tok), 0)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := stream.expect('}'); err != nil {
+		return nil, err
+	}
+
+	if _, err := stream.dec.Token(); !errors.Is(err, io.EOF) {
+		return nil, stream.fail(errors.New("unexpected data after top-level object"), 0)
+	}
+
+	for vertex, gloss := range glosses {
+		if err := g.SetGloss(vertex, gloss); err != nil {
+			return nil, err
+		}
+	}
+
+	err = validateGraph(g)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
+
+// json lines
+func (g *Graph) EncodeJsonLines(w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+
+	for _, vertex := range common.SortedKeys(g.adj) {
+		neighbors := g.adj[vertex]
+
+		if len(neighbors) == 0 && !g.hasTypedEdges(vertex) {
+			enc.Encode(jsonLineDTO{Word: g.Display(vertex)})
+
+			continue
+		}
+
+		for _, neighbor := range common.SortedKeys(neighbors) {
+			if common.CompareWords(vertex, neighbor) < 0 {
+				enc.Encode(jsonLineDTO{A: g.Display(vertex), B: g.Display(neighbor)})
+			}
+		}
+	}
+
+	for _, vertex := range common.SortedKeys(g.antonyms) {
+		for _, antonym := range common.SortedKeys(g.antonyms[vertex]) {
+			if common.CompareWords(vertex, antonym) < 0 {
+				enc.Encode(jsonLineDTO{A: g.Display(vertex), B: g.Display(antonym), Type: AntonymEdge.String()})
+			}
+		}
+	}
+
+	for _, vertex := range common.SortedKeys(g.broader) {
+		for _, b := range common.SortedKeys(g.broader[vertex]) {
+			enc.Encode(jsonLineDTO{A: g.Display(vertex), B: g.Display(b), Type: HypernymEdge.String()})
+		}
+	}
+
+	for _, vertex := range common.SortedKeys(g.glosses) {
+		enc.Encode(jsonLineDTO{Word: g.Display(vertex), Gloss: g.glosses[vertex]})
+	}
+
+	return bw.Flush()
+}
+
+func DecodeJsonLines(r io.Reader) (*Graph, error) {
+	g := NewGraph()
+	scanner := newLineScanner(r)
+
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := scanner.Bytes()
+
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+
+		var record jsonLineDTO
+		dec := json.NewDecoder(bytes.NewReader(line))
+		dec.DisallowUnknownFields()
+
+		if err := dec.Decode(&record); err != nil {
+			validationErr := jsonError(line, err)
+			validationErr.Line = lineNo
+
+			return nil, validationErr
+		}
+
+		var err error
+
+		switch {
+		case record.Word != "" && record.A == "" && record.B == "":
+			err = g.AddVertex(record.Word)
+
+			if err == nil && record.Gloss != "" {
+				err = g.SetGloss(record.Word, record.Gloss)
+			}
+
+		case record.Word == "" && record.A != "" && record.B != "":
+			t := SynonymEdge
+
+			if record.Type != "" {
+				t, err = ParseEdgeType(record.Type)
+			}
+
+			if err == nil {
+				err = g.AddTypedEdge(record.A, record.B, t)
+			}
+
+		default:
+			err = validationErrorf("expected either \"word\" or \"a\" and \"b\"")
+		}
+
+		if err != nil {
+			return nil, atPosition(err, lineNo, 0)
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, scanError(err)
+	}
+
+	err := validateGraph(g)
+
+	if err != nil {
+		return nil, err
+	}
+
+	return g, nil
+}
@@ -0,0 +1,140 @@
+package structpkg
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+const goldenRuns = 10
+
+// goldenDict builds the same dictionary in a different order on every run,
+// so the maps behind it are filled in a different order too.
+func goldenDict(t *testing.T, run int) *Dict {
+	t.Helper()
+
+	steps := []func(d *Dict) error{
+		func(d *Dict) error { return d.AddSynonyms("fast", "quick", "rapid", "swift") },
+		func(d *Dict) error { return d.AddSynonyms("slow", "sluggish", "leisurely") },
+		func(d *Dict) error { return d.AddSynonyms("быстрый", "скорый") },
+		func(d *Dict) error { return d.AddSynonyms("café", "coffee house", "coffee-shop") },
+		func(d *Dict) error { return d.AddWords("ёлка", "zebra") },
+		func(d *Dict) error { return d.AddAntonyms("fast", "slow") },
+		func(d *Dict) error { return d.AddHypernym("swift", "bird") },
+	}
+
+	d := NewDict()
+
+	for i := range steps {
+		if err := steps[(i+run)%len(steps)](d); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if _, err := d.AddSense("bank", "the land alongside a river"); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := d.AddSense("bank", "an institution that keeps money; \"safe\""); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.AddSynonyms("bank#2", "depository"); err != nil {
+		t.Fatal(err)
+	}
+
+	return d
+}
+
+func TestGoldenExports(t *testing.T) {
+	for _, format := range []string{"gob", "csv", "csvc", "json", "jsonl"} {
+		t.Run(format, func(t *testing.T) {
+			golden := filepath.Join("testdata", "export."+format+".golden")
+			var want []byte
+
+			for run := range goldenRuns {
+				var got bytes.Buffer
+
+				if err := goldenDict(t, run).Encode(&got, format); err != nil {
+					t.Fatal(err)
+				}
+
+				if run == 0 && *update {
+					if err := os.WriteFile(golden, got.Bytes(), 0644); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				if want == nil {
+					data, err := os.ReadFile(golden)
+
+					if err != nil {
+						t.Fatal(err)
+					}
+
+					want = data
+				}
+
+				if !bytes.Equal(got.Bytes(), want) {
+					t.Fatalf("run %d differs from %s:\n%s", run, golden, got.Bytes())
+				}
+			}
+		})
+	}
+}
+
+func TestDecodeGob(t *testing.T) {
+	var want bytes.Buffer
+
+	if err := goldenDict(t, 0).graph.EncodeGob(&want); err != nil {
+		t.Fatal(err)
+	}
+
+	legacy, err := os.ReadFile(filepath.Join("testdata", "legacy.gob"))
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name    string
+		data    []byte
+		wantErr bool
+	}{
+		{"stream", want.Bytes(), false},
+		{"single value of earlier versions", legacy, false},
+		{"truncated stream", want.Bytes()[:want.Len()-20], true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graph, err := DecodeGob(bytes.NewReader(tt.data))
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatal("DecodeGob() succeeded")
+				}
+
+				return
+			}
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got bytes.Buffer
+
+			if err := graph.EncodeGob(&got); err != nil {
+				t.Fatal(err)
+			}
+
+			if !bytes.Equal(got.Bytes(), want.Bytes()) {
+				t.Error("decoded graph encodes differently from the original")
+			}
+		})
+	}
+}
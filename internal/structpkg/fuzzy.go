@@ -0,0 +1,238 @@
+package structpkg
+
+import (
+	"slices"
+	"synodict-go/internal/common"
+)
+
+// fuzzyIndex is a BK-tree over the words of the graph (senses share one
+// entry), keyed by Damerau-Levenshtein distance. Removed words stay in the
+// tree as dead nodes until they make up half of it, then it is rebuilt.
+type fuzzyIndex struct {
+	root  *bkNode
+	nodes map[string]*bkNode
+	dead  int
+}
+
+type bkNode struct {
+	word     string
+	senses   int
+	children map[int]*bkNode
+}
+
+func newFuzzyIndex() *fuzzyIndex {
+	return &fuzzyIndex{nodes: make(map[string]*bkNode)}
+}
+
+func (fi *fuzzyIndex) add(word string) {
+	if node, ok := fi.nodes[word]; ok {
+		if node.senses == 0 {
+			fi.dead--
+		}
+
+		node.senses++
+		return
+	}
+
+	node := &bkNode{word: word, senses: 1}
+	fi.nodes[word] = node
+
+	if fi.root == nil {
+		fi.root = node
+		return
+	}
+
+	current := fi.root
+
+	for {
+		distance := wordDistance(word, current.word)
+		child, ok := current.children[distance]
+
+		if !ok {
+			if current.children == nil {
+				current.children = make(map[int]*bkNode)
+			}
+
+			current.children[distance] = node
+			return
+		}
+
+		current = child
+	}
+}
+
+func (fi *fuzzyIndex) remove(word string) {
+	node, ok := fi.nodes[word]
+
+	if !ok || node.senses == 0 {
+		return
+	}
+
+	node.senses--
+
+	if node.senses > 0 {
+		return
+	}
+
+	fi.dead++
+
+	if fi.dead*2 >= len(fi.nodes) {
+		fi.rebuild()
+	}
+}
+
+func (fi *fuzzyIndex) rebuild() {
+	nodes := fi.nodes
+	*fi = *newFuzzyIndex()
+
+	// sorted, so that the tree has the same shape every time
+	for _, word := range common.SortedKeys(nodes) {
+		for range nodes[word].senses {
+			fi.add(word)
+		}
+	}
+}
+
+type fuzzyMatch struct {
+	word     string
+	distance int
+}
+
+// search returns the live words at most maxDistance edits away from word,
+// closest first.
+func (fi *fuzzyIndex) search(word string, maxDistance int) []fuzzyMatch {
+	var matches []fuzzyMatch
+
+	if fi.root == nil {
+		return matches
+	}
+
+	stack := []*bkNode{fi.root}
+
+	for len(stack) > 0 {
+		node := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+
+		distance := wordDistance(word, node.word)
+
+		if distance <= maxDistance && node.senses > 0 {
+			matches = append(matches, fuzzyMatch{word: node.word, distance: distance})
+		}
+
+		for d, child := range node.children {
+			if d >= distance-maxDistance && d <= distance+maxDistance {
+				stack = append(stack, child)
+			}
+		}
+	}
+
+	slices.SortFunc(matches, func(a, b fuzzyMatch) int {
+		if a.distance != b.distance {
+			return a.distance - b.distance
+		}
+
+		return common.CompareWords(a.word, b.word)
+	})
+
+	return matches
+}
+
+// wordDistance is the optimal string alignment variant of the
+// Damerau-Levenshtein distance: insertions, deletions, substitutions and
+// transpositions of adjacent characters all cost one edit.
+func wordDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	current := make([]int, len(rb)+1)
+
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		current[0] = i
+
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+
+			current[j] = min(prev[j]+1, current[j-1]+1, prev[j-1]+cost)
+
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				current[j] = min(current[j], prev2[j-2]+1)
+			}
+		}
+
+		prev2, prev, current = prev, current, prev2
+	}
+
+	return prev[len(rb)]
+}
+
+// maxSuggestionDistance allows more typos the longer the word is.
+func maxSuggestionDistance(word string) int {
+	switch n := len([]rune(word)); {
+	case n <= 3:
+		return 1
+
+	case n <= 7:
+		return 2
+	}
+
+	return 3
+}
+
+func (g *Graph) fuzzyIndex() *fuzzyIndex {
+	if g.fuzzy != nil {
+		return g.fuzzy
+	}
+
+	g.fuzzy = newFuzzyIndex()
+
+	for _, vertex := range common.SortedKeys(g.adj) {
+		word, _, _ := splitSense(vertex)
+		g.fuzzy.add(word)
+	}
+
+	return g.fuzzy
+}
+
+func (g *Graph) indexFuzzy(vertex string) {
+	if g.fuzzy != nil {
+		word, _, _ := splitSense(vertex)
+		g.fuzzy.add(word)
+	}
+}
+
+func (g *Graph) unindexFuzzy(vertex string) {
+	if g.fuzzy != nil {
+		word, _, _ := splitSense(vertex)
+		g.fuzzy.remove(word)
+	}
+}
+
+// SimilarWords returns up to limit words (senses stripped) that are a few
+// typos away from word, closest first; word itself is not included.
+func (g *Graph) SimilarWords(word string, limit int) []string {
+	g.cacheMu.Lock()
+	defer g.cacheMu.Unlock()
+
+	word, _, _ = splitSense(word)
+	similar := []string{}
+
+	for _, match := range g.fuzzyIndex().search(word, maxSuggestionDistance(word)) {
+		if len(similar) == limit {
+			break
+		}
+
+		if match.word != word {
+			similar = append(similar, match.word)
+		}
+	}
+
+	return similar
+}
@@ -1,54 +1,104 @@
 package structpkg
 
 import (
-	"bytes"
-	"encoding/gob"
-	"fmt"
+	"maps"
+	"slices"
 	"strings"
+	"sync"
 	"synodict-go/internal/common"
 )
 
+// Graph is not safe for concurrent use, except that readers may run in
+// parallel: the lazily rebuilt indexes are guarded by cacheMu.
 type Graph struct {
-	adj map[string]common.Set
-}
-
-type graphDTO struct {
-	Adj map[string]common.Set
+	adj      map[string]common.Set
+	antonyms map[string]common.Set
+	broader  map[string]common.Set
+	narrower map[string]common.Set
+	glosses  map[string]string
+	display  map[string]string
+	index    *connectivityIndex
+	senses   map[string]common.Set
+	fuzzy    *fuzzyIndex
+	prefixes []string
+	cacheMu  sync.Mutex
+	observer func(op graphOp)
 }
 
 func NewGraph() *Graph {
-	return &Graph{adj: make(map[string]common.Set)}
-}
+	index := newConnectivityIndex()
+	index.valid = true
 
-func newGraphDTO() *graphDTO {
-	return &graphDTO{Adj: make(map[string]common.Set)}
+	return &Graph{
+		adj:      make(map[string]common.Set),
+		antonyms: make(map[string]common.Set),
+		broader:  make(map[string]common.Set),
+		narrower: make(map[string]common.Set),
+		glosses:  make(map[string]string),
+		display:  make(map[string]string),
+		index:    index,
+	}
 }
 
 func validateGraph(g *Graph) error {
 	for vertex, neighbors := range g.adj {
 		if vertex == "" {
-			return fmt.Errorf("graph validation failed: vertex cannot be empty")
+			return validationErrorf("vertex cannot be empty")
 		}
 
-		if strings.Contains(vertex, ";") {
-			return fmt.Errorf("graph validation failed: vertex %q contains invalid character \";\"", vertex)
+		if strings.HasPrefix(vertex, "#") {
+			return validationErrorf("vertex %q cannot start with \"#\"", vertex)
 		}
 
 		for neighbor := range neighbors {
 			if _, ok := g.adj[neighbor]; !ok {
-				return fmt.Errorf("graph validation failed: vertex %q referenced from %q does not exist", neighbor, vertex)
+				return validationErrorf("vertex %q referenced from %q does not exist", neighbor, vertex)
 			}
 
 			if _, ok := g.adj[neighbor][vertex]; !ok {
-				return fmt.Errorf("graph validation failed: edge %q → %q is not symmetric", vertex, neighbor)
+				return validationErrorf("edge %q → %q is not symmetric", vertex, neighbor)
 			}
 
 			if neighbor == vertex {
-				return fmt.Errorf("graph validation failed: self-loop detected at vertex %q", vertex)
+				return validationErrorf("self-loop detected at vertex %q", vertex)
 			}
 		}
 	}
 
+	for _, t := range []EdgeType{AntonymEdge, HypernymEdge} {
+		forward, reverse := g.typedSets(t)
+
+		for vertex, targets := range forward {
+			if _, ok := g.adj[vertex]; !ok {
+				return validationErrorf("%s source %q does not exist", t, vertex)
+			}
+
+			for target := range targets {
+				if _, ok := g.adj[target]; !ok {
+					return validationErrorf("vertex %q referenced from %q as %s does not exist", target, vertex, t)
+				}
+
+				if _, ok := reverse[target][vertex]; !ok {
+					return validationErrorf("%s edge %q → %q has no reverse entry", t, vertex, target)
+				}
+
+				if target == vertex {
+					return validationErrorf("%s self-loop detected at vertex %q", t, vertex)
+				}
+			}
+		}
+	}
+
+	for vertex, gloss := range g.glosses {
+		if _, ok := g.adj[vertex]; !ok {
+			return validationErrorf("gloss owner %q does not exist", vertex)
+		}
+
+		if strings.ContainsAny(gloss, "\r\n") {
+			return validationErrorf("gloss of %q contains invalid characters", vertex)
+		}
+	}
+
 	return nil
 }
 
@@ -89,19 +139,38 @@ func (g *Graph) bfs(start string, target ...string) common.Set {
 }
 
 func (g *Graph) AddVertex(vertex string) error {
+	return g.AddVertexAs(vertex, "")
+}
+
+// AddVertexAs adds the vertex with the spelling it is displayed with, if
+// that differs from the vertex itself.
+func (g *Graph) AddVertexAs(vertex, display string) error {
 	if g.HasVertex(vertex) {
 		return nil
 	}
 
-	if strings.Contains(vertex, ";") {
-		return fmt.Errorf("graph validation error: vertex %q contains invalid character \";\"", vertex)
+	if vertex == "" {
+		return validationErrorf("vertex cannot be empty string")
 	}
 
-	if vertex == "" {
-		return fmt.Errorf("graph validation error: vertex cannot be empty string")
+	if strings.HasPrefix(vertex, "#") {
+		return validationErrorf("vertex %q cannot start with \"#\"", vertex)
 	}
 
 	g.adj[vertex] = make(common.Set)
+	g.indexSense(vertex)
+	g.indexFuzzy(vertex)
+	g.prefixes = nil
+
+	if display != "" && display != vertex {
+		g.display[vertex] = display
+	}
+
+	if g.index.valid {
+		g.index.add(vertex)
+	}
+
+	g.emit(graphOp{kind: opAddVertex, a: vertex, b: g.display[vertex]})
 
 	return nil
 }
@@ -116,13 +185,12 @@ func (g *Graph) RemoveVertex(vertex string) {
 		return
 	}
 
-	neighbors := g.adj[vertex]
-
-	for neighbor := range neighbors {
-		delete(g.adj[neighbor], vertex)
+	for neighbor := range g.adj[vertex] {
+		g.RemoveEdge(vertex, neighbor)
 	}
 
-	delete(g.adj, vertex)
+	g.removeTypedEdges(vertex)
+	g.removeIsolatedVertex(vertex)
 }
 
 func (g *Graph) RemoveVertexIfIsolated(vertex string) {
@@ -130,11 +198,41 @@ func (g *Graph) RemoveVertexIfIsolated(vertex string) {
 		return
 	}
 
-	if len(g.adj[vertex]) == 0 {
-		delete(g.adj, vertex)
+	if len(g.adj[vertex]) == 0 && !g.hasTypedEdges(vertex) {
+		g.removeIsolatedVertex(vertex)
 	}
 }
 
+func (g *Graph) removeIsolatedVertex(vertex string) {
+	g.SetGloss(vertex, "")
+
+	display := g.display[vertex]
+
+	delete(g.adj, vertex)
+	delete(g.display, vertex)
+	g.unindexSense(vertex)
+	g.unindexFuzzy(vertex)
+	g.prefixes = nil
+	g.index.removeSingleton(vertex)
+	g.emit(graphOp{kind: opRemoveVertex, a: vertex, b: display})
+}
+
+func (g *Graph) Display(vertex string) string {
+	if display, ok := g.display[vertex]; ok {
+		return display
+	}
+
+	return vertex
+}
+
+func (g *Graph) displayAll(vertices []string) []string {
+	for i, vertex := range vertices {
+		vertices[i] = g.Display(vertex)
+	}
+
+	return vertices
+}
+
 func (g *Graph) GetVertices() []string {
 	var vertices []string
 
@@ -142,31 +240,44 @@ func (g *Graph) GetVertices() []string {
 		vertices = append(vertices, vertex)
 	}
 
+	common.SortWords(vertices)
+
 	return vertices
 }
 
 func (g *Graph) GetConnectivityGroups() [][]string {
-	visited := make(common.Set)
+	g.cacheMu.Lock()
+	defer g.cacheMu.Unlock()
+
+	index := g.connectivity()
+	groupIndexes := make(map[string]int, index.count)
 	var groups [][]string
 
 	for vertex := range g.adj {
-		if _, ok := visited[vertex]; ok {
-			continue
-		}
+		root := index.find(vertex)
+		i, ok := groupIndexes[root]
 
-		var group []string
-
-		for v := range g.bfs(vertex) {
-			group = append(group, v)
-			visited[v] = common.Void{}
+		if !ok {
+			i = len(groups)
+			groupIndexes[root] = i
+			groups = append(groups, make([]string, 0, index.size[root]))
 		}
 
-		groups = append(groups, group)
+		groups[i] = append(groups[i], vertex)
 	}
 
+	common.SortGroups(groups)
+
 	return groups
 }
 
+func (g *Graph) ConnectivityGroupCount() int {
+	g.cacheMu.Lock()
+	defer g.cacheMu.Unlock()
+
+	return g.connectivity().count
+}
+
 func (g *Graph) AddEdge(a, b string) error {
 	if g.HasEdge(a, b) {
 		return nil
@@ -195,6 +306,12 @@ func (g *Graph) AddEdge(a, b string) error {
 	g.adj[a][b] = common.Void{}
 	g.adj[b][a] = common.Void{}
 
+	if g.index.valid {
+		g.index.union(a, b)
+	}
+
+	g.emit(graphOp{kind: opAddEdge, a: a, b: b, edge: SynonymEdge})
+
 	return nil
 }
 
@@ -214,6 +331,8 @@ func (g *Graph) RemoveEdge(a, b string) {
 
 	delete(g.adj[a], b)
 	delete(g.adj[b], a)
+	g.invalidateIndex()
+	g.emit(graphOp{kind: opRemoveEdge, a: a, b: b, edge: SynonymEdge})
 }
 
 func (g *Graph) RemoveEdgeAndCleanup(a, b string) {
@@ -234,6 +353,8 @@ func (g *Graph) GetNeighbors(vertex string) []string {
 		neighbors = append(neighbors, neighbor)
 	}
 
+	common.SortWords(neighbors)
+
 	return neighbors
 }
 
@@ -246,240 +367,177 @@ func (g *Graph) GetConnectedVertices(vertex string) []string {
 		}
 	}
 
+	common.SortWords(connected)
+
 	return connected
 }
 
 func (g *Graph) ConnectedVertexCount(vertex string) int {
-	visited := g.bfs(vertex)
-
-	if len(visited) == 0 {
+	if !g.HasVertex(vertex) {
 		return 0
-	} else {
-		return len(visited) - 1
 	}
+
+	g.cacheMu.Lock()
+	defer g.cacheMu.Unlock()
+
+	return g.connectivity().componentSize(vertex) - 1
 }
 
 func (g *Graph) AreConnected(a, b string) bool {
-	if !g.HasVertex(a) {
+	if !g.HasVertex(a) || !g.HasVertex(b) {
 		return false
 	}
 
-	_, ok := g.bfs(a, b)[b]
+	g.cacheMu.Lock()
+	defer g.cacheMu.Unlock()
 
-	return ok
-}
+	index := g.connectivity()
 
-func (g *Graph) Cleanup() {
-	for vertex := range g.adj {
-		g.RemoveVertexIfIsolated(vertex)
-	}
+	return index.find(a) == index.find(b)
 }
 
-func (g *Graph) Order() int {
-	return len(g.adj)
-}
-
-func (g *Graph) IsEmpty() bool {
-	return g.Order() == 0
-}
+func (g *Graph) ShortestPath(a, b string) []string {
+	var path []string
 
-func (g *Graph) Clone() *Graph {
-	clone := NewGraph()
+	if !g.HasVertex(a) || !g.HasVertex(b) {
+		return path
+	}
 
-	if g == nil || g.adj == nil {
-		return clone
+	if a == b {
+		return []string{a}
 	}
 
-	for vertex, neighbors := range g.adj {
-		clonedNeighbors := make(common.Set)
+	parents := map[string]string{a: a}
+	queue := []string{a}
+	current_index := 0
 
-		for neighbor := range neighbors {
-			clonedNeighbors[neighbor] = common.Void{}
-		}
+	for len(queue) > current_index {
+		current := queue[current_index]
+		current_index++
 
-		clone.adj[vertex] = clonedNeighbors
-	}
+		for _, neighbor := range common.SortedKeys(g.adj[current]) {
+			if _, ok := parents[neighbor]; ok {
+				continue
+			}
 
-	return clone
-}
+			parents[neighbor] = current
 
-func (g *graphDTO) cloneDTO() *graphDTO {
-	clone := newGraphDTO()
+			if neighbor == b {
+				for v := b; v != a; v = parents[v] {
+					path = append(path, v)
+				}
 
-	if g == nil || g.Adj == nil {
-		return clone
-	}
+				path = append(path, a)
+				slices.Reverse(path)
 
-	for vertex, neighbors := range g.Adj {
-		clonedNeighbors := make(common.Set)
+				return path
+			}
 
-		for neighbor := range neighbors {
-			clonedNeighbors[neighbor] = common.Void{}
+			queue = append(queue, neighbor)
 		}
-
-		clone.Adj[vertex] = clonedNeighbors
 	}
 
-	return clone
-}
-
-func (g *Graph) makeDTO() *graphDTO {
-	clone := g.Clone()
-	dto := newGraphDTO()
-	dto.Adj = clone.adj
-
-	return dto
+	return path
 }
 
-func (g *graphDTO) fromDTO() *Graph {
-	clone := g.cloneDTO()
-	graph := NewGraph()
-	graph.adj = clone.Adj
-
-	return graph
-}
+// AllShortestPaths returns the shortest paths from a to b, at most limit of
+// them (all when limit is 0): their number can grow exponentially with the
+// length of the path.
+func (g *Graph) AllShortestPaths(a, b string, limit int) [][]string {
+	var paths [][]string
 
-func (g *Graph) SerializeGob() []byte {
-	var buf bytes.Buffer
-	enc := gob.NewEncoder(&buf)
-	enc.Encode(g.makeDTO())
-
-	return buf.Bytes()
-}
-
-func DeserializeGob(data []byte) (*Graph, error) {
-	g := newGraphDTO()
-
-	if len(data) == 0 {
-		return NewGraph(), nil
+	if !g.HasVertex(a) || !g.HasVertex(b) {
+		return paths
 	}
 
-	dec := gob.NewDecoder(bytes.NewReader(data))
-
-	if err := dec.Decode(&g); err != nil {
-		return nil, fmt.Errorf("graph deserialization failed: %w", err)
+	if a == b {
+		return [][]string{{a}}
 	}
 
-	graph := g.fromDTO()
-	err := validateGraph(graph)
-
-	if err != nil {
-		return nil, err
-	}
+	distances := map[string]int{a: 0}
+	parents := make(map[string][]string)
+	queue := []string{a}
+	current_index := 0
 
-	return graph, nil
-}
+	for len(queue) > current_index {
+		current := queue[current_index]
+		current_index++
 
-func (g *Graph) SerializeCsv() []byte {
-	var buf bytes.Buffer
+		if d, ok := distances[b]; ok && distances[current] >= d {
+			break
+		}
 
-	for vertex, neighbors := range g.adj {
-		fmt.Fprint(&buf, vertex)
+		for _, neighbor := range common.SortedKeys(g.adj[current]) {
+			d, ok := distances[neighbor]
 
-		for neighbor := range neighbors {
-			fmt.Fprintf(&buf, ";%s", neighbor)
+			if !ok {
+				distances[neighbor] = distances[current] + 1
+				parents[neighbor] = []string{current}
+				queue = append(queue, neighbor)
+			} else if d == distances[current]+1 {
+				parents[neighbor] = append(parents[neighbor], current)
+			}
 		}
-
-		buf.WriteByte('\n')
 	}
 
-	return buf.Bytes()
-}
-
-func DeserializeCsv(data []byte) (*Graph, error) {
-	g := NewGraph()
-
-	if len(data) == 0 {
-		return g, nil
+	if _, ok := distances[b]; !ok {
+		return paths
 	}
 
-	text := strings.TrimSpace(string(data))
-	text = strings.ReplaceAll(text, "\r\n", "\n")
-	lines := strings.Split(text, "\n")
-
-	for _, line := range lines {
-		if line == "" {
-			continue
-		}
+	var walk func(vertex string, suffix []string)
 
-		vertices := strings.Split(line, ";")
+	walk = func(vertex string, suffix []string) {
+		suffix = append([]string{vertex}, suffix...)
 
-		if _, ok := g.adj[vertices[0]]; ok {
-			return nil, fmt.Errorf("graph validation failed: duplicate vertex %q found", vertices[0])
+		if vertex == a {
+			paths = append(paths, suffix)
+			return
 		}
 
-		g.adj[vertices[0]] = make(common.Set)
+		for _, parent := range parents[vertex] {
+			if limit > 0 && len(paths) == limit {
+				return
+			}
 
-		for i := 1; i < len(vertices); i++ {
-			g.adj[vertices[0]][vertices[i]] = common.Void{}
+			walk(parent, suffix)
 		}
 	}
 
-	err := validateGraph(g)
+	walk(b, nil)
 
-	if err != nil {
-		return nil, err
-	}
-
-	return g, nil
+	return paths
 }
 
-func (g *Graph) SerializeCsvCondensed() []byte {
-	var buf bytes.Buffer
-
-	for vertex, neighbors := range g.adj {
-		if len(neighbors) == 0 {
-			fmt.Fprintf(&buf, "%s\n", vertex)
-
-			continue
-		}
-
-		for neighbor := range neighbors {
-			if neighbor > vertex {
-				fmt.Fprintf(&buf, "%s;%s\n", vertex, neighbor)
-			}
-		}
+func (g *Graph) Cleanup() {
+	for vertex := range g.adj {
+		g.RemoveVertexIfIsolated(vertex)
 	}
-
-	return buf.Bytes()
 }
 
-func DeserializeCsvCondensed(data []byte) (*Graph, error) {
-	g := NewGraph()
-
-	if len(data) == 0 {
-		return g, nil
-	}
-
-	text := strings.TrimSpace(string(data))
-	text = strings.ReplaceAll(text, "\r\n", "\n")
-	lines := strings.Split(text, "\n")
-
-	for _, line := range lines {
-		if line == "" {
-			continue
-		}
-
-		vertices := strings.Split(line, ";")
-		var err error
-
-		switch len(vertices) {
-		case 1:
-			err = g.AddVertex(vertices[0])
+func (g *Graph) Order() int {
+	return len(g.adj)
+}
 
-		case 2:
-			err = g.AddEdge(vertices[0], vertices[1])
+func (g *Graph) IsEmpty() bool {
+	return g.Order() == 0
+}
 
-		default:
-			err = fmt.Errorf("graph deserialization failed: invalid number of vertices in line %q", line)
-		}
+func (g *Graph) Clone() *Graph {
+	clone := NewGraph()
 
-		if err != nil {
-			return nil, err
-		}
+	if g == nil || g.adj == nil {
+		return clone
 	}
 
-	return g, nil
+	clone.adj = cloneSets(g.adj)
+	clone.antonyms = cloneSets(g.antonyms)
+	clone.broader = cloneSets(g.broader)
+	clone.narrower = cloneSets(g.narrower)
+	clone.glosses = maps.Clone(g.glosses)
+	clone.display = maps.Clone(g.display)
+	clone.invalidateIndex()
+
+	return clone
 }
 
 func (g *Graph) Merge(graph *Graph) error {
@@ -494,6 +552,31 @@ func (g *Graph) Merge(graph *Graph) error {
 }
 
 func (g *Graph) MergeUnsafe(graph *Graph) {
+	g.invalidateIndex()
+	g.senses = nil
+	g.fuzzy = nil
+	g.prefixes = nil
+	maps.Copy(g.glosses, graph.glosses)
+
+	for vertex, display := range graph.display {
+		if !g.HasVertex(vertex) {
+			g.display[vertex] = display
+		}
+	}
+
+	for vertex, antonyms := range graph.antonyms {
+		for antonym := range antonyms {
+			addToSet(g.antonyms, vertex, antonym)
+		}
+	}
+
+	for vertex, broader := range graph.broader {
+		for b := range broader {
+			addToSet(g.broader, vertex, b)
+			addToSet(g.narrower, b, vertex)
+		}
+	}
+
 	for vertex, neighbors := range graph.adj {
 		if _, ok := g.adj[vertex]; !ok {
 			g.adj[vertex] = neighbors
@@ -518,4 +601,13 @@ func (g *Graph) FromGraph(graph *Graph) error {
 
 func (g *Graph) FromGraphUnsafe(graph *Graph) {
 	g.adj = graph.adj
+	g.antonyms = graph.antonyms
+	g.broader = graph.broader
+	g.narrower = graph.narrower
+	g.glosses = graph.glosses
+	g.display = graph.display
+	g.senses = nil
+	g.fuzzy = nil
+	g.prefixes = nil
+	g.invalidateIndex()
 }
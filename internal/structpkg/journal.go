@@ -0,0 +1,260 @@
+package structpkg
+
+const DefaultHistoryDepth = 100
+
+type opKind uint8
+
+const (
+	opAddVertex opKind = iota
+	opRemoveVertex
+	opAddEdge
+	opRemoveEdge
+	opSetGloss
+)
+
+type graphOp struct {
+	kind      opKind
+	a, b      string
+	edge      EdgeType
+	gloss     string
+	prevGloss string
+}
+
+type journalStep struct {
+	op    graphOp
+	graph *Graph
+}
+
+type journalEntry struct {
+	label string
+	steps []journalStep
+}
+
+type journal struct {
+	undo    []*journalEntry
+	redo    []*journalEntry
+	depth   int
+	pending *journalEntry
+}
+
+func newJournal() *journal {
+	return &journal{depth: DefaultHistoryDepth}
+}
+
+func (op graphOp) inverse() graphOp {
+	inverse := op
+
+	switch op.kind {
+	case opAddVertex:
+		inverse.kind = opRemoveVertex
+
+	case opRemoveVertex:
+		inverse.kind = opAddVertex
+
+	case opAddEdge:
+		inverse.kind = opRemoveEdge
+
+	case opRemoveEdge:
+		inverse.kind = opAddEdge
+
+	case opSetGloss:
+		inverse.gloss, inverse.prevGloss = op.prevGloss, op.gloss
+	}
+
+	return inverse
+}
+
+func (g *Graph) emit(op graphOp) {
+	if g.observer != nil {
+		g.observer(op)
+	}
+}
+
+func (g *Graph) apply(op graphOp) {
+	switch op.kind {
+	case opAddVertex:
+		g.AddVertexAs(op.a, op.b)
+
+	case opRemoveVertex:
+		g.RemoveVertex(op.a)
+
+	case opAddEdge:
+		g.AddTypedEdge(op.a, op.b, op.edge)
+
+	case opRemoveEdge:
+		g.RemoveTypedEdge(op.a, op.b, op.edge)
+
+	case opSetGloss:
+		g.SetGloss(op.a, op.gloss)
+	}
+}
+
+func (d *Dict) setGraph(g *Graph) {
+	g.observer = d.record
+	d.graph = g
+}
+
+func (d *Dict) replaceGraph(g *Graph) {
+	d.touched = true
+	d.logInvalid = true
+
+	if d.journal.pending != nil {
+		d.journal.pending.steps = append(d.journal.pending.steps, journalStep{graph: d.graph})
+	}
+
+	d.setGraph(g)
+}
+
+func (d *Dict) record(op graphOp) {
+	d.touched = true
+
+	if d.changeLog != nil {
+		d.logged = append(d.logged, op)
+	}
+
+	if d.journal.pending != nil {
+		d.journal.pending.steps = append(d.journal.pending.steps, journalStep{op: op})
+	}
+}
+
+func (d *Dict) begin(label string) {
+	d.mu.Lock()
+	d.touched = false
+
+	if d.journal.depth > 0 {
+		d.journal.pending = &journalEntry{label: label}
+	}
+}
+
+func (d *Dict) commit() {
+	defer d.mu.Unlock()
+
+	if d.touched {
+		d.changes++
+	}
+
+	d.flushChangeLog()
+
+	entry := d.journal.pending
+	d.journal.pending = nil
+
+	if entry == nil || len(entry.steps) == 0 {
+		return
+	}
+
+	d.journal.undo = append(d.journal.undo, entry)
+	d.journal.redo = nil
+	d.trimHistory()
+}
+
+func (d *Dict) trimHistory() {
+	if excess := len(d.journal.undo) - d.journal.depth; excess > 0 {
+		d.journal.undo = d.journal.undo[excess:]
+	}
+}
+
+func (d *Dict) swapGraph(step *journalStep) {
+	d.logInvalid = true
+
+	current := d.graph
+	d.setGraph(step.graph)
+	step.graph = current
+}
+
+func (d *Dict) Undo(n int) []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	defer d.flushChangeLog()
+
+	var labels []string
+
+	for ; n > 0 && len(d.journal.undo) > 0; n-- {
+		entry := d.journal.undo[len(d.journal.undo)-1]
+		d.journal.undo = d.journal.undo[:len(d.journal.undo)-1]
+
+		for i := len(entry.steps) - 1; i >= 0; i-- {
+			if entry.steps[i].graph != nil {
+				d.swapGraph(&entry.steps[i])
+			} else {
+				d.graph.apply(entry.steps[i].op.inverse())
+			}
+		}
+
+		d.journal.redo = append(d.journal.redo, entry)
+		d.changes++
+		labels = append(labels, entry.label)
+	}
+
+	return labels
+}
+
+func (d *Dict) Redo(n int) []string {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	defer d.flushChangeLog()
+
+	var labels []string
+
+	for ; n > 0 && len(d.journal.redo) > 0; n-- {
+		entry := d.journal.redo[len(d.journal.redo)-1]
+		d.journal.redo = d.journal.redo[:len(d.journal.redo)-1]
+
+		for i := range entry.steps {
+			if entry.steps[i].graph != nil {
+				d.swapGraph(&entry.steps[i])
+			} else {
+				d.graph.apply(entry.steps[i].op)
+			}
+		}
+
+		d.journal.undo = append(d.journal.undo, entry)
+		d.changes++
+		labels = append(labels, entry.label)
+	}
+
+	return labels
+}
+
+func (d *Dict) History() ([]string, int) {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	var labels []string
+
+	for i := len(d.journal.undo) - 1; i >= 0; i-- {
+		labels = append(labels, d.journal.undo[i].label)
+	}
+
+	return labels, len(d.journal.redo)
+}
+
+func (d *Dict) ClearHistory() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.journal.undo = nil
+	d.journal.redo = nil
+}
+
+func (d *Dict) HistoryDepth() int {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.journal.depth
+}
+
+func (d *Dict) SetHistoryDepth(depth int) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if depth < 0 {
+		depth = 0
+	}
+
+	d.journal.depth = depth
+	d.trimHistory()
+
+	if depth == 0 {
+		d.journal.redo = nil
+	}
+}
@@ -0,0 +1,78 @@
+package structpkg
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestApplyIsOneHistoryEntry(t *testing.T) {
+	for _, overwrite := range []bool{false, true} {
+		t.Run(fmt.Sprintf("overwrite=%v", overwrite), func(t *testing.T) {
+			d := NewDict()
+
+			if err := d.AddSynonyms("old", "older"); err != nil {
+				t.Fatal(err)
+			}
+
+			graph, err := d.DecodeGraph(strings.NewReader(`{"synonyms": {"new": ["newer"], "newer": ["new"]}}`), "json")
+
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			d.Apply(graph, "new.json", overwrite)
+
+			want := "[new newer]"
+
+			if !overwrite {
+				want = "[new newer old older]"
+			}
+
+			if got := fmt.Sprint(d.GetWords()); got != want {
+				t.Errorf("after Apply words are %s, want %s", got, want)
+			}
+
+			if undone := d.Undo(1); len(undone) != 1 {
+				t.Fatalf("Undo(1) = %v", undone)
+			}
+
+			if got := fmt.Sprint(d.GetWords()); got != "[old older]" {
+				t.Errorf("after one Undo words are %s, want [old older]", got)
+			}
+		})
+	}
+}
+
+func TestApplyIfEmpty(t *testing.T) {
+	d := NewDict()
+	graph, err := d.DecodeGraph(strings.NewReader(`{"synonyms": {"new": ["newer"], "newer": ["new"]}}`), "json")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// added after the import was read, while it waited to be applied
+	if err := d.AddSynonyms("old", "older"); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := d.ApplyIfEmpty(graph, "new.json"); !errors.Is(err, ErrNotEmpty) {
+		t.Fatalf("ApplyIfEmpty() = %v, want ErrNotEmpty", err)
+	}
+
+	if got := fmt.Sprint(d.GetWords()); got != "[old older]" {
+		t.Errorf("after a refused import words are %s, want [old older]", got)
+	}
+
+	d.Clear()
+
+	if err := d.ApplyIfEmpty(graph, "new.json"); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := fmt.Sprint(d.GetWords()); got != "[new newer]" {
+		t.Errorf("after ApplyIfEmpty words are %s, want [new newer]", got)
+	}
+}
@@ -0,0 +1,219 @@
+package structpkg
+
+import (
+	"fmt"
+	"strings"
+	"synodict-go/internal/common"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
+	"golang.org/x/text/unicode/norm"
+)
+
+// NormalForm is the Unicode normalization form words are matched in.
+type NormalForm int
+
+const (
+	NFC NormalForm = iota
+	NFKC
+	NoNormalForm
+)
+
+type CaseFolding int
+
+const (
+	FoldCase CaseFolding = iota
+	// FoldTurkish follows Turkish and Azerbaijani rules: "I" folds to the
+	// dotless "ı" and "İ" to "i".
+	FoldTurkish
+	KeepCase
+)
+
+// Normalization decides which spellings are the same word. Every word is
+// stored and looked up under its key: the word with runs of whitespace
+// collapsed into single spaces (unless KeepSpaces), in the normal form and
+// folded to lower case. With KeepDisplay, the spelling a word was first
+// added or imported with is kept and shown instead of the key. The zero
+// value is the default.
+type Normalization struct {
+	Form        NormalForm
+	Case        CaseFolding
+	KeepSpaces  bool
+	KeepDisplay bool
+}
+
+func (n Normalization) check() error {
+	if n.Form < NFC || n.Form > NoNormalForm {
+		return fmt.Errorf("dictionary: unknown normal form %d", n.Form)
+	}
+
+	if n.Case < FoldCase || n.Case > KeepCase {
+		return fmt.Errorf("dictionary: unknown case folding %d", n.Case)
+	}
+
+	return nil
+}
+
+// Display returns the spelling kept for the word: only whitespace and
+// canonically equivalent sequences (NFC) are normalized.
+func (n Normalization) Display(word string) string {
+	if !n.KeepSpaces {
+		word = strings.Join(strings.Fields(word), " ")
+	}
+
+	if n.Form != NoNormalForm {
+		word = norm.NFC.String(word)
+	}
+
+	return word
+}
+
+func (n Normalization) Key(word string) string {
+	word = n.Display(word)
+
+	if n.Form == NFKC {
+		word = norm.NFKC.String(word)
+	}
+
+	switch n.Case {
+	case FoldCase:
+		word = cases.Lower(language.Und).String(word)
+
+	case FoldTurkish:
+		word = cases.Lower(language.Turkish).String(word)
+	}
+
+	return word
+}
+
+// normalized re-keys the graph; words that get the same key are merged.
+// It returns g itself when every vertex already is its own key.
+func (g *Graph) normalized(n Normalization) *Graph {
+	keys := make(map[string]string, len(g.adj))
+	changed := !n.KeepDisplay && len(g.display) > 0
+
+	for vertex := range g.adj {
+		keys[vertex] = n.Key(vertex)
+		changed = changed || keys[vertex] != vertex
+	}
+
+	if !changed {
+		return g
+	}
+
+	out := NewGraph()
+
+	// sorted, so that the first spelling of merged words wins every time
+	for _, vertex := range common.SortedKeys(g.adj) {
+		key := keys[vertex]
+
+		if _, ok := out.adj[key]; !ok {
+			out.adj[key] = make(common.Set)
+
+			if display := n.Display(g.Display(vertex)); n.KeepDisplay && display != key {
+				out.display[key] = display
+			}
+		}
+
+		if gloss, ok := g.glosses[vertex]; ok && out.glosses[key] == "" {
+			out.glosses[key] = gloss
+		}
+	}
+
+	rekey := func(from, to map[string]common.Set) {
+		for vertex, targets := range from {
+			for target := range targets {
+				if keys[vertex] != keys[target] {
+					addToSet(to, keys[vertex], keys[target])
+				}
+			}
+		}
+	}
+
+	rekey(g.adj, out.adj)
+	rekey(g.antonyms, out.antonyms)
+	rekey(g.broader, out.broader)
+	out.narrower = reverseSets(out.broader)
+	out.invalidateIndex()
+
+	return out
+}
+
+func (d *Dict) key(word string) string {
+	return d.norm.Key(word)
+}
+
+func (d *Dict) keys(words []string) []string {
+	keys := make([]string, len(words))
+
+	for i, word := range words {
+		keys[i] = d.norm.Key(word)
+	}
+
+	return keys
+}
+
+// addWord adds the vertex for word under its key, remembering the spelling
+// if the dictionary keeps display forms.
+func (d *Dict) addWord(word string) {
+	display := ""
+
+	if d.norm.KeepDisplay {
+		display = canonicalSense(d.norm.Display(word))
+	}
+
+	d.graph.AddVertexAs(canonicalSense(d.key(word)), display)
+}
+
+func (d *Dict) normalizeOp(op graphOp) graphOp {
+	op.a = d.key(op.a)
+
+	switch op.kind {
+	case opAddVertex, opRemoveVertex:
+		if !d.norm.KeepDisplay {
+			op.b = ""
+		} else if op.b != "" {
+			op.b = canonicalSense(d.norm.Display(op.b))
+		}
+
+	default:
+		op.b = d.key(op.b)
+	}
+
+	return op
+}
+
+func (d *Dict) Normalization() Normalization {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	return d.norm
+}
+
+// SetNormalization re-keys the words already in the dictionary, merging
+// those that now are the same word. Re-keying clears the history, whose
+// changes refer to the old keys.
+func (d *Dict) SetNormalization(n Normalization) error {
+	if err := n.check(); err != nil {
+		return err
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.norm = n
+	graph := d.graph.normalized(n)
+
+	if graph == d.graph {
+		return nil
+	}
+
+	d.setGraph(graph)
+	d.journal.undo = nil
+	d.journal.redo = nil
+	d.changes++
+	d.logInvalid = true
+	d.flushChangeLog()
+
+	return nil
+}
@@ -0,0 +1,128 @@
+package structpkg
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+)
+
+// ChangeLog receives every change made to a Dict as encoded operations.
+// Append gets the operations of one change at a time; Invalidate is called
+// instead when a change replaces the whole graph (import, clear or undoing
+// one of them) and cannot be expressed as operations.
+type ChangeLog interface {
+	Append(records [][]byte)
+	Invalidate()
+}
+
+var errCorruptOp = errors.New("corrupt operation record")
+
+func appendString(buf []byte, s string) []byte {
+	buf = binary.AppendUvarint(buf, uint64(len(s)))
+	return append(buf, s...)
+}
+
+func readString(buf []byte) (string, []byte, error) {
+	n, size := binary.Uvarint(buf)
+
+	if size <= 0 || uint64(len(buf)-size) < n {
+		return "", nil, errCorruptOp
+	}
+
+	buf = buf[size:]
+
+	return string(buf[:n]), buf[n:], nil
+}
+
+func encodeOp(op graphOp) []byte {
+	buf := []byte{byte(op.kind), byte(op.edge)}
+	buf = appendString(buf, op.a)
+	buf = appendString(buf, op.b)
+	buf = appendString(buf, op.gloss)
+
+	return buf
+}
+
+func decodeOp(record []byte) (graphOp, error) {
+	var op graphOp
+	var err error
+
+	if len(record) < 2 || opKind(record[0]) > opSetGloss {
+		return op, errCorruptOp
+	}
+
+	op.kind, op.edge = opKind(record[0]), EdgeType(record[1])
+	rest := record[2:]
+
+	for _, field := range []*string{&op.a, &op.b, &op.gloss} {
+		*field, rest, err = readString(rest)
+
+		if err != nil {
+			return op, err
+		}
+	}
+
+	if len(rest) != 0 {
+		return op, errCorruptOp
+	}
+
+	return op, nil
+}
+
+func (d *Dict) flushChangeLog() {
+	if d.changeLog == nil {
+		return
+	}
+
+	switch {
+	case d.logInvalid:
+		d.changeLog.Invalidate()
+
+	case len(d.logged) > 0:
+		records := make([][]byte, 0, len(d.logged))
+
+		for _, op := range d.logged {
+			records = append(records, encodeOp(op))
+		}
+
+		d.changeLog.Append(records)
+	}
+
+	d.logged = nil
+	d.logInvalid = false
+}
+
+func (d *Dict) SetChangeLog(log ChangeLog) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.changeLog = log
+	d.logged = nil
+	d.logInvalid = false
+}
+
+// Replay applies logged operations without recording them in the history.
+// Operations only ever set a fact (an edge exists, a vertex is gone), so
+// replaying operations the graph already contains changes nothing; the
+// caller must not replay operations that an unlogged change (import,
+// clear) came after, as they would undo it.
+func (d *Dict) Replay(records [][]byte) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	log := d.changeLog
+	d.changeLog = nil
+	defer func() { d.changeLog = log }()
+
+	for i, record := range records {
+		op, err := decodeOp(record)
+
+		if err != nil {
+			return fmt.Errorf("replay failed: record %d: %w", i+1, err)
+		}
+
+		d.graph.apply(d.normalizeOp(op))
+	}
+
+	return nil
+}
@@ -0,0 +1,113 @@
+package structpkg
+
+import (
+	"fmt"
+	"testing"
+)
+
+// layeredDict links start to every word of the first layer, every word of
+// a layer to every word of the next one, and the last layer to end, so
+// there are width^depth shortest paths from start to end.
+func layeredDict(t *testing.T, width, depth int) *Dict {
+	t.Helper()
+
+	d := NewDict()
+	previous := []string{"start"}
+
+	for layer := range depth {
+		current := []string{}
+
+		for i := range width {
+			word := fmt.Sprintf("layer %c %c", 'a'+layer, 'a'+i)
+			current = append(current, word)
+
+			for _, p := range previous {
+				if err := d.AddSynonyms(p, word); err != nil {
+					t.Fatal(err)
+				}
+			}
+		}
+
+		previous = current
+	}
+
+	for _, p := range previous {
+		if err := d.AddSynonyms(p, "end"); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	return d
+}
+
+// TestExplainSynonymyDoesNotEnumeratePaths asks for one of the 4^11
+// shortest paths; enumerating them all would run into the test timeout.
+func TestExplainSynonymyDoesNotEnumeratePaths(t *testing.T) {
+	d := layeredDict(t, 4, 11)
+
+	path, err := d.ExplainSynonymy("start", "end")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(path) != 13 || path[0] != "start" || path[12] != "end" {
+		t.Errorf("ExplainSynonymy = %v, want a path of 13 words from start to end", path)
+	}
+}
+
+func TestExplainSynonymyAllIsCapped(t *testing.T) {
+	d := layeredDict(t, 4, 11)
+
+	paths, err := d.ExplainSynonymyAll("start", "end")
+
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(paths) != MaxShortestPaths {
+		t.Fatalf("ExplainSynonymyAll returned %d paths, want %d", len(paths), MaxShortestPaths)
+	}
+
+	seen := make(map[string]bool)
+
+	for _, path := range paths {
+		if len(path) != 13 {
+			t.Errorf("path %v is not a shortest path", path)
+		}
+
+		seen[fmt.Sprint(path)] = true
+	}
+
+	if len(seen) != len(paths) {
+		t.Errorf("ExplainSynonymyAll returned duplicate paths")
+	}
+}
+
+func TestAllShortestPaths(t *testing.T) {
+	g := NewGraph()
+
+	for _, edge := range [][2]string{{"a", "b"}, {"a", "c"}, {"b", "d"}, {"c", "d"}, {"d", "e"}, {"a", "x"}, {"x", "y"}, {"y", "e"}} {
+		if err := g.AddEdge(edge[0], edge[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		limit int
+		want  string
+	}{
+		{0, "[[a b d e] [a c d e] [a x y e]]"},
+		{2, "[[a b d e] [a c d e]]"},
+	}
+
+	for _, tt := range tests {
+		if got := fmt.Sprint(g.AllShortestPaths("a", "e", tt.limit)); got != tt.want {
+			t.Errorf("AllShortestPaths(a, e, %d) = %s, want %s", tt.limit, got, tt.want)
+		}
+	}
+
+	if got := fmt.Sprint(g.ShortestPath("a", "e")); got != "[a b d e]" {
+		t.Errorf("ShortestPath(a, e) = %s", got)
+	}
+}
@@ -0,0 +1,135 @@
+package structpkg
+
+import (
+	"fmt"
+	"io"
+	"synodict-go/internal/common"
+)
+
+// ImportPreview summarizes what importing a graph would change. GroupsMerged
+// existing synonym groups would be joined into MergedInto groups, and
+// LargestGroup is the largest group that does not exist yet.
+type ImportPreview struct {
+	WordsAdded     []string
+	WordsRemoved   int
+	EdgesAdded     int
+	RelationsAdded int
+	GroupsMerged   int
+	MergedInto     int
+	LargestGroup   []string
+}
+
+func (g *Graph) previewMerge(other *Graph) ImportPreview {
+	preview := ImportPreview{WordsAdded: []string{}, LargestGroup: []string{}}
+
+	for vertex, neighbors := range other.adj {
+		if !g.HasVertex(vertex) {
+			preview.WordsAdded = append(preview.WordsAdded, vertex)
+		}
+
+		for neighbor := range neighbors {
+			if vertex < neighbor && !g.HasEdge(vertex, neighbor) {
+				preview.EdgesAdded++
+			}
+		}
+	}
+
+	common.SortWords(preview.WordsAdded)
+	other.displayAll(preview.WordsAdded)
+
+	for vertex, antonyms := range other.antonyms {
+		for antonym := range antonyms {
+			if vertex < antonym && !g.HasTypedEdge(vertex, antonym, AntonymEdge) {
+				preview.RelationsAdded++
+			}
+		}
+	}
+
+	for vertex, broader := range other.broader {
+		for b := range broader {
+			if !g.HasTypedEdge(vertex, b, HypernymEdge) {
+				preview.RelationsAdded++
+			}
+		}
+	}
+
+	oldGroups := make(map[string]int)
+
+	for i, group := range g.GetConnectivityGroups() {
+		for _, vertex := range group {
+			oldGroups[vertex] = i
+		}
+	}
+
+	merged := g.Clone()
+	merged.MergeUnsafe(other.Clone())
+
+	for _, group := range merged.GetConnectivityGroups() {
+		joined := make(map[int]bool)
+		added := 0
+
+		for _, vertex := range group {
+			if i, ok := oldGroups[vertex]; ok {
+				joined[i] = true
+			} else {
+				added++
+			}
+		}
+
+		if len(joined) > 1 {
+			preview.GroupsMerged += len(joined)
+			preview.MergedInto++
+		}
+
+		if (len(joined) != 1 || added > 0) && len(group) > len(preview.LargestGroup) {
+			preview.LargestGroup = group
+		}
+	}
+
+	common.SortWords(preview.LargestGroup)
+	merged.displayAll(preview.LargestGroup)
+
+	return preview
+}
+
+// DecodeGraph decodes a dictionary without touching the current one, so it
+// can be previewed with Preview and imported with Apply.
+func (d *Dict) DecodeGraph(r io.Reader, format string) (*Graph, error) {
+	decoder := getFormatDecoder(d, format)
+
+	if decoder == nil {
+		return nil, fmt.Errorf("import failed: %w: %s", ErrUnsupportedFormat, format)
+	}
+
+	graph, err := decoder(r)
+
+	if err != nil {
+		return nil, err
+	}
+
+	normalized := graph.normalized(d.Normalization())
+
+	if normalized != graph {
+		err = validateGraph(normalized)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return normalized, nil
+}
+
+func (d *Dict) Preview(g *Graph, overwrite bool) ImportPreview {
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	if overwrite {
+		preview := NewGraph().previewMerge(g)
+		preview.WordsRemoved = d.graph.Order()
+
+		return preview
+	}
+
+	return d.graph.previewMerge(g)
+}
@@ -0,0 +1,195 @@
+package structpkg
+
+import (
+	"fmt"
+	"synodict-go/internal/common"
+)
+
+type EdgeType uint8
+
+const (
+	SynonymEdge EdgeType = iota
+	AntonymEdge
+	HypernymEdge
+	HyponymEdge
+)
+
+var edgeTypeNames = map[EdgeType]string{
+	SynonymEdge:  "synonym",
+	AntonymEdge:  "antonym",
+	HypernymEdge: "hypernym",
+	HyponymEdge:  "hyponym",
+}
+
+func (t EdgeType) String() string {
+	if name, ok := edgeTypeNames[t]; ok {
+		return name
+	}
+
+	return fmt.Sprintf("edge type %d", t)
+}
+
+func ParseEdgeType(name string) (EdgeType, error) {
+	for t, n := range edgeTypeNames {
+		if n == name {
+			return t, nil
+		}
+	}
+
+	return 0, validationErrorf("unknown edge type %q", name)
+}
+
+func addToSet(sets map[string]common.Set, key, value string) {
+	if sets[key] == nil {
+		sets[key] = make(common.Set)
+	}
+
+	sets[key][value] = common.Void{}
+}
+
+func removeFromSet(sets map[string]common.Set, key, value string) {
+	delete(sets[key], value)
+
+	if len(sets[key]) == 0 {
+		delete(sets, key)
+	}
+}
+
+func cloneSets(sets map[string]common.Set) map[string]common.Set {
+	clone := make(map[string]common.Set, len(sets))
+
+	for key, values := range sets {
+		clonedValues := make(common.Set, len(values))
+
+		for value := range values {
+			clonedValues[value] = common.Void{}
+		}
+
+		clone[key] = clonedValues
+	}
+
+	return clone
+}
+
+func reverseSets(sets map[string]common.Set) map[string]common.Set {
+	reversed := make(map[string]common.Set)
+
+	for key, values := range sets {
+		for value := range values {
+			addToSet(reversed, value, key)
+		}
+	}
+
+	return reversed
+}
+
+func (g *Graph) typedSets(t EdgeType) (map[string]common.Set, map[string]common.Set) {
+	switch t {
+	case AntonymEdge:
+		return g.antonyms, g.antonyms
+
+	case HypernymEdge:
+		return g.broader, g.narrower
+
+	case HyponymEdge:
+		return g.narrower, g.broader
+	}
+
+	return nil, nil
+}
+
+func (g *Graph) AddTypedEdge(a, b string, t EdgeType) error {
+	if t == SynonymEdge {
+		return g.AddEdge(a, b)
+	}
+
+	forward, reverse := g.typedSets(t)
+
+	if forward == nil {
+		return validationErrorf("unknown %s", t)
+	}
+
+	if a == b {
+		return validationErrorf("%s edge cannot link vertex %q to itself", t, a)
+	}
+
+	if g.HasTypedEdge(a, b, t) {
+		return nil
+	}
+
+	for _, vertex := range []string{a, b} {
+		err := g.AddVertex(vertex)
+
+		if err != nil {
+			return err
+		}
+	}
+
+	addToSet(forward, a, b)
+	addToSet(reverse, b, a)
+	g.emit(graphOp{kind: opAddEdge, a: a, b: b, edge: t})
+
+	return nil
+}
+
+func (g *Graph) HasTypedEdge(a, b string, t EdgeType) bool {
+	if t == SynonymEdge {
+		return g.HasEdge(a, b)
+	}
+
+	forward, _ := g.typedSets(t)
+	_, ok := forward[a][b]
+
+	return ok
+}
+
+func (g *Graph) RemoveTypedEdge(a, b string, t EdgeType) {
+	if t == SynonymEdge {
+		g.RemoveEdge(a, b)
+		return
+	}
+
+	if !g.HasTypedEdge(a, b, t) {
+		return
+	}
+
+	forward, reverse := g.typedSets(t)
+	removeFromSet(forward, a, b)
+	removeFromSet(reverse, b, a)
+	g.emit(graphOp{kind: opRemoveEdge, a: a, b: b, edge: t})
+}
+
+func (g *Graph) GetTypedNeighbors(vertex string, t EdgeType) []string {
+	if t == SynonymEdge {
+		return g.GetNeighbors(vertex)
+	}
+
+	var neighbors []string
+	forward, _ := g.typedSets(t)
+
+	for neighbor := range forward[vertex] {
+		neighbors = append(neighbors, neighbor)
+	}
+
+	common.SortWords(neighbors)
+
+	return neighbors
+}
+
+func (g *Graph) hasTypedEdges(vertex string) bool {
+	return len(g.antonyms[vertex]) > 0 || len(g.broader[vertex]) > 0 || len(g.narrower[vertex]) > 0
+}
+
+func (g *Graph) removeTypedEdges(vertex string) {
+	for antonym := range g.antonyms[vertex] {
+		g.RemoveTypedEdge(vertex, antonym, AntonymEdge)
+	}
+
+	for broader := range g.broader[vertex] {
+		g.RemoveTypedEdge(vertex, broader, HypernymEdge)
+	}
+
+	for narrower := range g.narrower[vertex] {
+		g.RemoveTypedEdge(narrower, vertex, HypernymEdge)
+	}
+}
@@ -0,0 +1,200 @@
+package structpkg
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"synodict-go/internal/common"
+)
+
+// SearchResult is one page of the words matching a search; Total counts
+// the matches on every page.
+type SearchResult struct {
+	Words []string
+	Total int
+}
+
+// prefixIndex returns the vertices in byte order, so that the vertices
+// starting with a prefix form one run that can be found by binary search.
+// Adding or removing a vertex drops the index, and the next search builds
+// it again, so bulk changes do not pay for keeping it sorted.
+func (g *Graph) prefixIndex() []string {
+	if g.prefixes != nil {
+		return g.prefixes
+	}
+
+	g.prefixes = make([]string, 0, len(g.adj))
+
+	for vertex := range g.adj {
+		g.prefixes = append(g.prefixes, vertex)
+	}
+
+	slices.Sort(g.prefixes)
+
+	return g.prefixes
+}
+
+// Match returns the vertices whose word (sense stripped) matches re, in
+// dictionary order. Only vertices starting with prefix are looked at, which
+// must hold for every match.
+func (g *Graph) Match(re *regexp.Regexp, prefix string) []string {
+	g.cacheMu.Lock()
+	defer g.cacheMu.Unlock()
+
+	index := g.prefixIndex()
+	start, _ := slices.BinarySearch(index, prefix)
+	var matches []string
+
+	for _, vertex := range index[start:] {
+		if !strings.HasPrefix(vertex, prefix) {
+			break
+		}
+
+		if word, _, _ := splitSense(vertex); re.MatchString(word) {
+			matches = append(matches, vertex)
+		}
+	}
+
+	common.SortWords(matches)
+
+	return matches
+}
+
+// globToRegexp translates a glob (* for any run of characters, ? for one,
+// [abc], [a-z] and [!abc] for a set, \ to match the next character
+// literally) into an anchored regular expression, and returns the literal
+// prefix every match starts with.
+func globToRegexp(glob string) (string, string, error) {
+	var re, prefix strings.Builder
+	literal := true
+	runes := []rune(glob)
+
+	re.WriteString(`^(?:`)
+
+	for i := 0; i < len(runes); i++ {
+		switch r := runes[i]; r {
+		case '*':
+			re.WriteString(`.*`)
+			literal = false
+
+		case '?':
+			re.WriteString(`.`)
+			literal = false
+
+		case '[':
+			end := i + 1
+
+			if end < len(runes) && (runes[end] == '!' || runes[end] == '^') {
+				end++
+			}
+
+			// a ] right after the opening bracket is part of the set
+			if end < len(runes) && runes[end] == ']' {
+				end++
+			}
+
+			for end < len(runes) && runes[end] != ']' {
+				end++
+			}
+
+			if end == len(runes) {
+				return "", "", fmt.Errorf("unterminated [ at character %d", i+1)
+			}
+
+			set := runes[i+1 : end]
+
+			if len(set) > 0 && set[0] == '!' {
+				set = append([]rune{'^'}, set[1:]...)
+			}
+
+			re.WriteString(`[` + strings.ReplaceAll(string(set), `\`, `\\`) + `]`)
+			literal = false
+			i = end
+
+		case '\\':
+			if i+1 < len(runes) {
+				i++
+				r = runes[i]
+			}
+
+			fallthrough
+
+		default:
+			re.WriteString(regexp.QuoteMeta(string(r)))
+
+			if literal {
+				prefix.WriteRune(r)
+			}
+		}
+	}
+
+	re.WriteString(`)$`)
+
+	return re.String(), prefix.String(), nil
+}
+
+// compileQuery reads a search query: /regexp/ matches anywhere in the word
+// unless anchored, anything else is a glob that must match the whole word
+// (so fast* is a prefix search).
+func (d *Dict) compileQuery(query string) (*regexp.Regexp, string, error) {
+	if len(query) >= 2 && strings.HasPrefix(query, "/") && strings.HasSuffix(query, "/") {
+		pattern := query[1 : len(query)-1]
+
+		if d.norm.Case != KeepCase {
+			pattern = "(?i)" + pattern
+		}
+
+		re, err := regexp.Compile(pattern)
+
+		if err != nil {
+			return nil, "", err
+		}
+
+		return re, "", nil
+	}
+
+	pattern, prefix, err := globToRegexp(d.key(query))
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	re, err := regexp.Compile(pattern)
+
+	if err != nil {
+		return nil, "", err
+	}
+
+	return re, prefix, nil
+}
+
+// Search returns the words matching query in dictionary order, skipping
+// the first offset matches and returning at most limit (0 for all).
+func (d *Dict) Search(query string, offset, limit int) (SearchResult, error) {
+	if offset < 0 || limit < 0 {
+		return SearchResult{}, errors.New("dictionary: search offset and limit cannot be negative")
+	}
+
+	d.mu.RLock()
+	defer d.mu.RUnlock()
+
+	re, prefix, err := d.compileQuery(query)
+
+	if err != nil {
+		return SearchResult{}, fmt.Errorf("dictionary: invalid search query %q: %w", query, err)
+	}
+
+	matches := d.graph.Match(re, prefix)
+	page := matches[min(offset, len(matches)):]
+
+	if limit > 0 && len(page) > limit {
+		page = page[:limit]
+	}
+
+	return SearchResult{
+		Words: d.graph.displayAll(slices.Clone(page)),
+		Total: len(matches),
+	}, nil
+}
@@ -0,0 +1,39 @@
+package structpkg
+
+import (
+	"fmt"
+	"testing"
+)
+
+func TestSearchSeesChangesAfterSearching(t *testing.T) {
+	d := NewDict()
+
+	if err := d.AddSynonyms("fast", "fastidious", "quick"); err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		change func() error
+		want   string
+	}{
+		{func() error { return nil }, "[fast fastidious]"},
+		{func() error { return d.AddWords("faster") }, "[fast faster fastidious]"},
+		{func() error { return d.RemoveWords("fastidious") }, "[fast faster]"},
+	}
+
+	for _, step := range steps {
+		if err := step.change(); err != nil {
+			t.Fatal(err)
+		}
+
+		result, err := d.Search("fast*", 0, 10)
+
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if got := fmt.Sprint(result.Words); got != step.want {
+			t.Errorf("Search(fast*) = %s, want %s", got, step.want)
+		}
+	}
+}
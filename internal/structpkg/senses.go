@@ -0,0 +1,132 @@
+package structpkg
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"synodict-go/internal/common"
+)
+
+func splitSense(vertex string) (string, int, bool) {
+	i := strings.LastIndex(vertex, "#")
+
+	if i <= 0 {
+		return vertex, 1, false
+	}
+
+	id, err := strconv.Atoi(vertex[i+1:])
+
+	if err != nil || id < 1 {
+		return vertex, 1, false
+	}
+
+	return vertex[:i], id, true
+}
+
+func senseKey(word string, id int) string {
+	if id <= 1 {
+		return word
+	}
+
+	return fmt.Sprintf("%s#%d", word, id)
+}
+
+func canonicalSense(vertex string) string {
+	word, id, _ := splitSense(vertex)
+	return senseKey(word, id)
+}
+
+func (g *Graph) senseIndex() map[string]common.Set {
+	if g.senses != nil {
+		return g.senses
+	}
+
+	g.senses = make(map[string]common.Set)
+
+	for vertex := range g.adj {
+		word, _, _ := splitSense(vertex)
+		addToSet(g.senses, word, vertex)
+	}
+
+	return g.senses
+}
+
+func (g *Graph) indexSense(vertex string) {
+	if g.senses != nil {
+		word, _, _ := splitSense(vertex)
+		addToSet(g.senses, word, vertex)
+	}
+}
+
+func (g *Graph) unindexSense(vertex string) {
+	if g.senses != nil {
+		word, _, _ := splitSense(vertex)
+		removeFromSet(g.senses, word, vertex)
+	}
+}
+
+func (g *Graph) GetSenses(word string) []string {
+	g.cacheMu.Lock()
+	defer g.cacheMu.Unlock()
+
+	var senses []string
+
+	for vertex := range g.senseIndex()[word] {
+		senses = append(senses, vertex)
+	}
+
+	slices.SortFunc(senses, func(a, b string) int {
+		_, idA, _ := splitSense(a)
+		_, idB, _ := splitSense(b)
+
+		return idA - idB
+	})
+
+	return senses
+}
+
+func (g *Graph) NextSenseID(word string) int {
+	g.cacheMu.Lock()
+	defer g.cacheMu.Unlock()
+
+	next := 1
+
+	for vertex := range g.senseIndex()[word] {
+		if _, id, _ := splitSense(vertex); id >= next {
+			next = id + 1
+		}
+	}
+
+	return next
+}
+
+func (g *Graph) SetGloss(vertex, gloss string) error {
+	if !g.HasVertex(vertex) {
+		return validationErrorf("vertex %q does not exist", vertex)
+	}
+
+	if strings.ContainsAny(gloss, "\r\n") {
+		return validationErrorf("gloss of %q contains invalid characters", vertex)
+	}
+
+	prev := g.glosses[vertex]
+
+	if gloss == prev {
+		return nil
+	}
+
+	if gloss == "" {
+		delete(g.glosses, vertex)
+	} else {
+		g.glosses[vertex] = gloss
+	}
+
+	g.emit(graphOp{kind: opSetGloss, a: vertex, gloss: gloss, prevGloss: prev})
+
+	return nil
+}
+
+func (g *Graph) GetGloss(vertex string) string {
+	return g.glosses[vertex]
+}
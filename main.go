@@ -1,17 +1,91 @@
 package main
 
 import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
 	"synodict-go/internal/cmdpkg"
 	"synodict-go/internal/common"
 	"synodict-go/internal/iopkg"
+	"synodict-go/internal/srvpkg"
+	"time"
+
+	"golang.org/x/text/language"
 )
 
-func main() {
+type commandList []string
+
+func (c *commandList) String() string {
+	return strings.Join(*c, "; ")
+}
+
+func (c *commandList) Set(value string) error {
+	*c = append(*c, value)
+	return nil
+}
+
+func isTerminal(file *os.File) bool {
+	info, err := file.Stat()
+
+	if err != nil {
+		return true
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+func runBatch(script string, commands []string, keepGoing bool) int {
+	var sources []io.Reader
+
+	if script != "" {
+		file, err := os.Open(script)
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s\n", err)
+			return cmdpkg.ExitSyntaxError
+		}
+
+		defer file.Close()
+		sources = append(sources, file, strings.NewReader("\n"))
+	}
+
+	if len(commands) > 0 {
+		sources = append(sources, strings.NewReader(strings.Join(commands, "\n")))
+	}
+
+	if len(sources) == 0 {
+		sources = append(sources, os.Stdin)
+	}
+
+	return cmdpkg.RunBatch(io.MultiReader(sources...), os.Stdout, os.Stderr, keepGoing)
+}
+
+func saveOnExit(errFormat, warningFormat string, warnLost bool) bool {
+	err := cmdpkg.Save()
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, errFormat, err)
+	}
+
+	if warning := cmdpkg.UnsavedWarning(); warning != "" && (warnLost || err != nil) {
+		fmt.Fprintf(os.Stderr, warningFormat, warning)
+	}
+
+	return err == nil
+}
+
+func runInteractive(autosaveInterval time.Duration) {
 	IORequestCh := make(chan iopkg.IORequest)
 	exitCh := make(chan common.Void)
+	stopCh := make(chan common.Void)
 
 	go iopkg.Request(IORequestCh, exitCh)
 	go cmdpkg.Run(IORequestCh, exitCh)
+	go cmdpkg.AutosaveEvery(autosaveInterval, stopCh, func(err error) {
+		fmt.Fprintf(os.Stderr, "~ ERROR ~ %s\n", err)
+	})
 
 	IORequestCh <- iopkg.IORequest{
 		Out:     true,
@@ -21,5 +95,119 @@ func main() {
 
 	<-exitCh
 
+	close(stopCh)
 	close(IORequestCh)
+
+	saveOnExit("~ ERROR ~ %s\n", "~ WARNING ~ %s\n", true)
+}
+
+func runServer(addr string, autosaveInterval time.Duration) int {
+	fmt.Fprintf(os.Stderr, "serving on %s\n", addr)
+	stopCh := make(chan common.Void)
+
+	go cmdpkg.AutosaveEvery(autosaveInterval, stopCh, func(err error) {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+	})
+
+	err := srvpkg.NewServer(cmdpkg.Dictionary()).ListenAndServe(addr)
+	close(stopCh)
+
+	exitCode := cmdpkg.ExitOK
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		exitCode = cmdpkg.ExitCommandError
+	}
+
+	if !saveOnExit("error: %s\n", "warning: %s\n", false) {
+		exitCode = cmdpkg.ExitCommandError
+	}
+
+	return exitCode
+}
+
+func main() {
+	var commands commandList
+
+	flag.Var(&commands, "c", "run the command without prompts (can be repeated)")
+	script := flag.String("f", "", "run commands from the script file without prompts")
+	interactive := flag.Bool("i", false, "start the interactive mode even if stdin is not a terminal")
+	keepGoing := flag.Bool("k", false, "keep running commands after an error")
+	output := flag.String("o", cmdpkg.OutputText, "output format: text, json or tsv")
+	serve := flag.String("serve", "", "serve the dictionary over HTTP on the address (e.g. :8080)")
+	backups := flag.Int("backups", 0, "keep this many previous versions of exported files as <file>.bak.N")
+	db := flag.String("db", "", "load the dictionary from the working file and save it back automatically")
+	autosaveEvery := flag.Int("autosave-every", cmdpkg.DefaultAutosaveEvery, "fold the change log into the working file after this many changes (0 disables)")
+	autosaveInterval := flag.Duration("autosave-interval", cmdpkg.DefaultAutosaveInterval, "save changes that could not be logged this often (0 disables)")
+	csvDelimiter := flag.String("csv-delimiter", "semicolon", "field delimiter of exported csv files: semicolon, comma or tab")
+	csvHeader := flag.Bool("csv-header", false, "start exported csv files with a header row")
+	wordScripts := flag.String("word-scripts", "", "comma-separated scripts words may use, e.g. latin,cyrillic,greek (all scripts if empty)")
+	wordChars := flag.String("word-chars", "apostrophe", "comma-separated characters words may contain besides letters, spaces and hyphens: apostrophe, period, digits")
+	normalForm := flag.String("normalize", "nfc", "Unicode normalization form words are matched in: nfc, nfkc or none")
+	caseFold := flag.String("case-fold", "lower", "case folding of words: lower, turkish (dotted and dotless i) or none")
+	keepSpaces := flag.Bool("keep-spaces", false, "do not collapse runs of whitespace in words")
+	keepDisplay := flag.Bool("keep-display", false, "show and export words as first spelled, while matching them normalized")
+	maxWordLength := flag.Int("max-word-length", 0, "longest word accepted, in characters (0 disables the limit)")
+	collation := flag.String("collate", "und", "BCP 47 tag of the language whose rules order words, e.g. de, sv, ru (und for the root order)")
+	flag.Parse()
+
+	tag, err := language.Parse(*collation)
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "error: invalid collation language \"%s\"\n", *collation)
+		os.Exit(cmdpkg.ExitSyntaxError)
+	}
+
+	common.SetCollation(tag)
+
+	if err := cmdpkg.SetOutputMode(*output); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(cmdpkg.ExitSyntaxError)
+	}
+
+	cmdpkg.SetBackups(*backups)
+
+	if err := cmdpkg.SetCsvOptions(*csvDelimiter, *csvHeader); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(cmdpkg.ExitSyntaxError)
+	}
+
+	if err := cmdpkg.SetWordPolicy(*wordScripts, *wordChars, *maxWordLength); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(cmdpkg.ExitSyntaxError)
+	}
+
+	if err := cmdpkg.SetNormalization(*normalForm, *caseFold, *keepSpaces, *keepDisplay); err != nil {
+		fmt.Fprintf(os.Stderr, "error: %s\n", err)
+		os.Exit(cmdpkg.ExitSyntaxError)
+	}
+
+	if *db != "" {
+		warning, err := cmdpkg.OpenWorkingFile(*db, *autosaveEvery)
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "error: %s\n", err)
+			os.Exit(cmdpkg.ExitCommandError)
+		}
+
+		if warning != "" {
+			fmt.Fprintf(os.Stderr, "warning: %s\n", warning)
+		}
+	}
+
+	if *serve != "" {
+		os.Exit(runServer(*serve, *autosaveInterval))
+	}
+
+	if len(commands) > 0 || *script != "" || (!*interactive && !isTerminal(os.Stdin)) {
+		exitCode := runBatch(*script, commands, *keepGoing)
+
+		if !saveOnExit("error: %s\n", "warning: %s\n", false) {
+			exitCode = max(exitCode, cmdpkg.ExitCommandError)
+		}
+
+		os.Exit(exitCode)
+	}
+
+	runInteractive(*autosaveInterval)
 }
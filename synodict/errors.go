@@ -0,0 +1,39 @@
+package synodict
+
+import "synodict-go/internal/structpkg"
+
+// Sentinel errors; match them with errors.Is. A single call may report
+// several of them joined together.
+var (
+	ErrWordNotFound      = structpkg.ErrWordNotFound
+	ErrWordExists        = structpkg.ErrWordExists
+	ErrInvalidWord       = structpkg.ErrInvalidWord
+	ErrSelfRelation      = structpkg.ErrSelfRelation
+	ErrNotLinked         = structpkg.ErrNotLinked
+	ErrAlreadyLinked     = structpkg.ErrAlreadyLinked
+	ErrUnsupportedFormat = structpkg.ErrUnsupportedFormat
+	ErrNotEmpty          = structpkg.ErrNotEmpty
+)
+
+// WordError wraps one of the sentinel errors together with the words that
+// caused it; use errors.As to get at Words. ErrWordNotFound errors also
+// list similar words that do exist in Suggestions.
+type WordError = structpkg.WordError
+
+// ValidationError reports malformed import data. Line and Column are
+// 1-based and zero when the position is unknown.
+type ValidationError = structpkg.ValidationError
+
+// ErrorInfo breaks an error down for machine-readable output: a stable
+// Code (word_not_found, word_exists, invalid_word, self_relation,
+// not_linked, already_linked, unsupported_format, not_empty,
+// validation_failed, file_not_found, or error for anything else), the
+// message, the words it is about with suggestions for unknown ones, and
+// the position of validation errors.
+type ErrorInfo = structpkg.ErrorInfo
+
+// DescribeError fills an ErrorInfo from a single error; split errors
+// joined with errors.Join first.
+func DescribeError(err error) ErrorInfo {
+	return structpkg.DescribeError(err)
+}
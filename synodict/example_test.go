@@ -0,0 +1,95 @@
+package synodict_test
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"synodict-go/synodict"
+)
+
+func Example() {
+	d := synodict.New()
+
+	if err := d.AddSynonyms("fast", "quick"); err != nil {
+		panic(err)
+	}
+
+	// synonymy is transitive: rapid joins the group of fast and quick
+	if err := d.AddSynonyms("quick", "rapid"); err != nil {
+		panic(err)
+	}
+
+	synonyms, _ := d.Synonyms("fast")
+	ok, _ := d.AreSynonyms("fast", "rapid")
+
+	fmt.Println(synonyms)
+	fmt.Println(ok)
+	// Output:
+	// [quick rapid]
+	// true
+}
+
+func ExampleDictionary_Import() {
+	d := synodict.New()
+	data := `{"synonyms": {"big": ["large"], "large": ["big"]}}`
+
+	if err := d.Import(strings.NewReader(data), synodict.FormatJSON); err != nil {
+		panic(err)
+	}
+
+	fmt.Println(d.Groups())
+	// Output:
+	// [[big large]]
+}
+
+func ExampleDictionary_Export() {
+	d := synodict.New()
+
+	if err := d.AddSynonyms("big", "large", "huge"); err != nil {
+		panic(err)
+	}
+
+	if err := d.Export(os.Stdout, synodict.FormatJSONLines); err != nil {
+		panic(err)
+	}
+	// Output:
+	// {"a":"big","b":"large"}
+	// {"a":"huge","b":"large"}
+}
+
+func ExampleWordError() {
+	d := synodict.New()
+
+	if err := d.AddSynonyms("word", "ward"); err != nil {
+		panic(err)
+	}
+
+	_, err := d.Synonyms("wrod")
+
+	fmt.Println(errors.Is(err, synodict.ErrWordNotFound))
+
+	var wordErr *synodict.WordError
+
+	if errors.As(err, &wordErr) {
+		fmt.Println(wordErr.Words, wordErr.Suggestions)
+	}
+	// Output:
+	// true
+	// [wrod] [word ward]
+}
+
+func ExampleDescribeError() {
+	d := synodict.New()
+
+	// RemoveWords joins one error per word it could not remove
+	err := d.RemoveWords("fast", "quick")
+
+	for _, err := range err.(interface{ Unwrap() []error }).Unwrap() {
+		info := synodict.DescribeError(err)
+		fmt.Println(info.Code, info.Words)
+	}
+	// Output:
+	// word_not_found [fast]
+	// word_not_found [quick]
+}
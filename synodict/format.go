@@ -0,0 +1,46 @@
+package synodict
+
+import (
+	"strings"
+	"synodict-go/internal/common"
+)
+
+// Format names a serialization format understood by Import and Export.
+type Format string
+
+const (
+	FormatGob          Format = "gob"
+	FormatCSV          Format = "csv"
+	FormatCSVCondensed Format = "csvc"
+	FormatJSON         Format = "json"
+	FormatJSONLines    Format = "jsonl"
+)
+
+// Formats returns every supported format.
+func Formats() []Format {
+	return []Format{FormatGob, FormatCSV, FormatCSVCondensed, FormatJSON, FormatJSONLines}
+}
+
+// ParseFormat checks that name is a supported format.
+func ParseFormat(name string) (Format, bool) {
+	_, ok := common.FormatFileExtensions[name]
+	return Format(name), ok
+}
+
+// Extension returns the file extension used for the format, including the dot.
+func (f Format) Extension() string {
+	return common.FormatFileExtensions[string(f)]
+}
+
+// FormatFromPath picks the format whose extension the path ends with.
+func FormatFromPath(path string) (Format, bool) {
+	var match Format
+
+	for _, format := range Formats() {
+		if strings.HasSuffix(path, format.Extension()) && len(format.Extension()) > len(match.Extension()) {
+			match = format
+		}
+	}
+
+	return match, match != ""
+}
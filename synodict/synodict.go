@@ -0,0 +1,514 @@
+// Package synodict is a synonym dictionary: words are linked as synonyms,
+// and synonymy is transitive, so every word belongs to exactly one synonym
+// group. Words can also be related as antonyms and broader/narrower terms,
+// and a word can have several senses ("bank", "bank#2") that keep unrelated
+// meanings in separate groups.
+//
+// A Dictionary is safe for concurrent use by multiple goroutines.
+package synodict
+
+import (
+	"fmt"
+	"io"
+	"synodict-go/internal/common"
+	"synodict-go/internal/corepkg"
+	"synodict-go/internal/stgpkg"
+	"synodict-go/internal/structpkg"
+
+	"golang.org/x/text/language"
+)
+
+// Dictionary is a synonym dictionary with an undo/redo history.
+// Methods that take several words report every problem at once: the
+// returned error joins one error per offending word.
+type Dictionary struct {
+	dict *structpkg.Dict
+}
+
+// ImportPreview summarizes what an import would change: the words and
+// links it adds, how many existing synonym groups it joins together
+// (GroupsMerged groups into MergedInto) and the largest group it creates.
+type ImportPreview = structpkg.ImportPreview
+
+// CSVOptions controls how the csv and csvc formats are written: the
+// field delimiter (';', ',' or '\t') and whether a header row comes first.
+// Fields containing the delimiter, quotes or line breaks are quoted as in
+// RFC 4180. Imports detect both settings on their own.
+type CSVOptions = structpkg.CsvOptions
+
+// DefaultCSVOptions writes ';'-delimited files without a header, like
+// earlier versions did.
+var DefaultCSVOptions = structpkg.DefaultCsvOptions
+
+// WordPolicy decides which words the dictionary accepts: letters of the
+// allowed Unicode scripts (named as in unicode.Scripts, e.g. "Latin",
+// "Greek", "Han"; every script when empty), spaces and hyphens, and
+// optionally apostrophes, periods and digits, up to MaxLength characters.
+type WordPolicy = common.WordPolicy
+
+// DefaultWordPolicy accepts letters of every script and apostrophes, with
+// no length limit.
+var DefaultWordPolicy = common.DefaultWordPolicy
+
+// Normalization decides which spellings are the same word. Words are
+// stored and looked up under a key: whitespace runs collapsed into single
+// spaces, brought to the normal form (NFC or NFKC) and folded to lower
+// case, optionally with Turkish rules for the dotted and dotless i. With
+// KeepDisplay, words are shown and exported as first spelled. The zero
+// value is the default: NFC, lower case, collapsed whitespace.
+type Normalization = structpkg.Normalization
+
+type NormalForm = structpkg.NormalForm
+
+type CaseFolding = structpkg.CaseFolding
+
+const (
+	NFC          = structpkg.NFC
+	NFKC         = structpkg.NFKC
+	NoNormalForm = structpkg.NoNormalForm
+	FoldCase     = structpkg.FoldCase
+	FoldTurkish  = structpkg.FoldTurkish
+	KeepCase     = structpkg.KeepCase
+)
+
+// Diff describes how one dictionary differs from another: added and
+// removed words, added and removed links (synonym links and typed
+// relations), and synonym groups that were merged or split.
+type Diff = structpkg.GraphDiff
+
+// Link is a direct link between two words; for broader term links B is
+// the broader term of A.
+type Link = structpkg.Link
+
+// GroupChange is a synonym group merge or split: the groups in From
+// became the groups in To.
+type GroupChange = structpkg.GroupChange
+
+// SearchResult is one page of the words matching a search; Total counts
+// the matches on every page.
+type SearchResult = structpkg.SearchResult
+
+// PendingImport is a decoded dictionary that has not been imported yet.
+type PendingImport struct {
+	dict   *structpkg.Dict
+	graph  *structpkg.Graph
+	source string
+}
+
+// Sense is one meaning of a word. Key is how the sense is addressed
+// ("bank" for the first sense, "bank#2" for the second one).
+type Sense struct {
+	Word  string
+	ID    int
+	Key   string
+	Gloss string
+}
+
+// Ref returns the explicit reference of the sense ("bank#1"), which never
+// expands to the other senses of the word.
+func (s Sense) Ref() string {
+	return fmt.Sprintf("%s#%d", s.Word, s.ID)
+}
+
+// SetCollation orders the words of every Dictionary, in listings and
+// exports, by the collation rules of the language; the default is the root
+// order (language.Und). Words the rules consider equal are ordered by their
+// bytes.
+func SetCollation(tag language.Tag) {
+	common.SetCollation(tag)
+}
+
+// New returns an empty dictionary.
+func New() *Dictionary {
+	return &Dictionary{dict: structpkg.NewDict()}
+}
+
+// FromCore wraps the Dict of a Core, which an internal package created and
+// keeps a handle on. Only internal packages can create a Core; use New.
+func FromCore(core corepkg.Core) *Dictionary {
+	return &Dictionary{dict: core.Dict()}
+}
+
+// Load reads a dictionary file into a new Dictionary.
+func Load(path string, format Format) (*Dictionary, error) {
+	x := New()
+	err := x.ImportFile(path, format)
+
+	if err != nil {
+		return nil, err
+	}
+
+	x.ClearHistory()
+
+	return x, nil
+}
+
+// AddSynonyms adds the words that are missing and links them as synonyms.
+func (x *Dictionary) AddSynonyms(words ...string) error {
+	return x.dict.AddSynonyms(words...)
+}
+
+// ValidateWords reports the words AddSynonyms and AddWords would reject
+// as ErrInvalidWord errors, without changing anything.
+func (x *Dictionary) ValidateWords(words ...string) error {
+	return x.dict.ValidateWords(words...)
+}
+
+// AddWords adds the words without linking them.
+func (x *Dictionary) AddWords(words ...string) error {
+	return x.dict.AddWords(words...)
+}
+
+// RemoveWords removes the words together with all of their links.
+func (x *Dictionary) RemoveWords(words ...string) error {
+	return x.dict.RemoveWords(words...)
+}
+
+// Unlink removes the direct synonym link between a and b.
+func (x *Dictionary) Unlink(a, b string) error {
+	return x.dict.UnlinkSynonyms(a, b)
+}
+
+// UnlinkAndCleanup removes the direct synonym link between a and b and
+// drops either word if it is left without any link.
+func (x *Dictionary) UnlinkAndCleanup(a, b string) error {
+	return x.dict.UnlinkSynonymsAndCleanup(a, b)
+}
+
+// AddAntonyms links a and b as antonyms.
+func (x *Dictionary) AddAntonyms(a, b string) error {
+	return x.dict.AddAntonyms(a, b)
+}
+
+// UnlinkAntonyms removes the antonym link between a and b.
+func (x *Dictionary) UnlinkAntonyms(a, b string) error {
+	return x.dict.UnlinkAntonyms(a, b)
+}
+
+// AddBroader records broader as a broader term (hypernym) of word.
+func (x *Dictionary) AddBroader(word, broader string) error {
+	return x.dict.AddHypernym(word, broader)
+}
+
+// UnlinkBroader removes the broader term link between word and broader.
+func (x *Dictionary) UnlinkBroader(word, broader string) error {
+	return x.dict.UnlinkHypernym(word, broader)
+}
+
+// AddSense adds a new sense of word and returns its key.
+func (x *Dictionary) AddSense(word, gloss string) (string, error) {
+	return x.dict.AddSense(word, gloss)
+}
+
+// SetGloss sets the gloss of a sense; an empty gloss removes it.
+func (x *Dictionary) SetGloss(sense, gloss string) error {
+	return x.dict.SetGloss(sense, gloss)
+}
+
+// Gloss returns the gloss of a sense, or "" if it has none.
+func (x *Dictionary) Gloss(sense string) string {
+	return x.dict.GetGloss(sense)
+}
+
+// Senses returns every sense of word ordered by ID.
+func (x *Dictionary) Senses(word string) ([]Sense, error) {
+	senses, err := x.dict.GetSenses(word)
+	result := make([]Sense, 0, len(senses))
+
+	for _, s := range senses {
+		result = append(result, Sense{Word: s.Word, ID: s.ID, Key: s.Key, Gloss: s.Gloss})
+	}
+
+	return result, err
+}
+
+// Synonyms returns every word in the synonym group of word, word excluded.
+func (x *Dictionary) Synonyms(word string) ([]string, error) {
+	return x.dict.GetSynomyms(word)
+}
+
+// DirectSynonyms returns only the words directly linked to word.
+func (x *Dictionary) DirectSynonyms(word string) ([]string, error) {
+	return x.dict.GetDirectSynonyms(word)
+}
+
+// SynonymCount returns the number of synonyms of word.
+func (x *Dictionary) SynonymCount(word string) (int, error) {
+	return x.dict.SynonymCount(word)
+}
+
+// Antonyms returns the antonyms of word.
+func (x *Dictionary) Antonyms(word string) ([]string, error) {
+	return x.dict.GetAntonyms(word)
+}
+
+// Broader returns the broader terms (hypernyms) of word.
+func (x *Dictionary) Broader(word string) ([]string, error) {
+	return x.dict.GetHypernyms(word)
+}
+
+// Narrower returns the narrower terms (hyponyms) of word.
+func (x *Dictionary) Narrower(word string) ([]string, error) {
+	return x.dict.GetHyponyms(word)
+}
+
+// AreSynonyms reports whether a and b are synonyms, directly or transitively.
+func (x *Dictionary) AreSynonyms(a, b string) (bool, error) {
+	return x.dict.AreSynonyms(a, b)
+}
+
+// AreDirectSynonyms reports whether a and b are directly linked.
+func (x *Dictionary) AreDirectSynonyms(a, b string) (bool, error) {
+	return x.dict.AreDirectSynonyms(a, b)
+}
+
+// SynonymousSenses returns the pairs of senses of a and b that are synonyms.
+func (x *Dictionary) SynonymousSenses(a, b string) ([][2]string, error) {
+	return x.dict.SynonymousSenses(a, b)
+}
+
+// Path returns the shortest chain of direct links from a to b, or nil if the
+// words are not synonyms.
+func (x *Dictionary) Path(a, b string) ([]string, error) {
+	return x.dict.ExplainSynonymy(a, b)
+}
+
+// MaxPaths caps how many chains AllPaths returns.
+const MaxPaths = structpkg.MaxShortestPaths
+
+// AllPaths returns every shortest chain of direct links from a to b, at
+// most MaxPaths of them.
+func (x *Dictionary) AllPaths(a, b string) ([][]string, error) {
+	return x.dict.ExplainSynonymyAll(a, b)
+}
+
+// Exists reports whether word (or any of its senses) is in the dictionary.
+func (x *Dictionary) Exists(word string) bool {
+	return x.dict.WordExists(word)
+}
+
+// Suggest returns up to limit words that are a few typos away from word,
+// closest first.
+func (x *Dictionary) Suggest(word string, limit int) []string {
+	return x.dict.Suggest(word, limit)
+}
+
+// Search returns the words (senses included) matching query, in dictionary
+// order: "fast*" and other globs (*, ?, [a-z], [!a-z]) must match the whole
+// word, "/^un.+able$/" is a regular expression. offset matches are skipped
+// and at most limit returned; limit 0 returns them all.
+func (x *Dictionary) Search(query string, offset, limit int) (SearchResult, error) {
+	return x.dict.Search(query, offset, limit)
+}
+
+// Words returns every word, senses included.
+func (x *Dictionary) Words() []string {
+	return x.dict.GetWords()
+}
+
+// WordCount returns the number of words, senses included.
+func (x *Dictionary) WordCount() int {
+	return x.dict.WordCount()
+}
+
+// Groups returns the synonym groups.
+func (x *Dictionary) Groups() [][]string {
+	return x.dict.GetSynonymGroups()
+}
+
+// GroupCount returns the number of synonym groups.
+func (x *Dictionary) GroupCount() int {
+	return x.dict.SynonymGroupCount()
+}
+
+// IsEmpty reports whether the dictionary has no words.
+func (x *Dictionary) IsEmpty() bool {
+	return x.dict.IsEmpty()
+}
+
+// Diff reports what changed from x to other.
+func (x *Dictionary) Diff(other *Dictionary) Diff {
+	return x.dict.Diff(other.dict)
+}
+
+// Changes returns a counter that grows with every change to the dictionary,
+// undo and redo included. Comparing two values tells whether anything
+// changed in between.
+func (x *Dictionary) Changes() uint64 {
+	return x.dict.Changes()
+}
+
+// Clear removes every word.
+func (x *Dictionary) Clear() {
+	x.dict.Clear()
+}
+
+// Cleanup removes every word that has no links.
+func (x *Dictionary) Cleanup() {
+	x.dict.Cleanup()
+}
+
+// Undo reverts up to n changes and returns their labels, newest first.
+func (x *Dictionary) Undo(n int) []string {
+	return x.dict.Undo(n)
+}
+
+// Redo re-applies up to n reverted changes and returns their labels.
+func (x *Dictionary) Redo(n int) []string {
+	return x.dict.Redo(n)
+}
+
+// History returns the labels of the changes that can be undone, newest
+// first, and the number of changes that can be redone.
+func (x *Dictionary) History() ([]string, int) {
+	return x.dict.History()
+}
+
+// ClearHistory forgets every recorded change.
+func (x *Dictionary) ClearHistory() {
+	x.dict.ClearHistory()
+}
+
+// HistoryDepth returns how many changes are remembered.
+func (x *Dictionary) HistoryDepth() int {
+	return x.dict.HistoryDepth()
+}
+
+// SetHistoryDepth sets how many changes are remembered; 0 disables the history.
+func (x *Dictionary) SetHistoryDepth(depth int) {
+	x.dict.SetHistoryDepth(depth)
+}
+
+func (x *Dictionary) Normalization() Normalization {
+	return x.dict.Normalization()
+}
+
+// SetNormalization changes how words are matched. Words already in the
+// dictionary are re-keyed, merging those that now are the same word, and
+// the history is cleared if anything changed.
+func (x *Dictionary) SetNormalization(n Normalization) error {
+	return x.dict.SetNormalization(n)
+}
+
+func (x *Dictionary) WordPolicy() WordPolicy {
+	return x.dict.WordPolicy()
+}
+
+// SetWordPolicy changes which words the dictionary accepts from now on.
+// Words already in the dictionary and words in imported files are not
+// checked against it.
+func (x *Dictionary) SetWordPolicy(policy WordPolicy) error {
+	return x.dict.SetWordPolicy(policy)
+}
+
+func (x *Dictionary) CSVOptions() CSVOptions {
+	return x.dict.CsvOptions()
+}
+
+// SetCSVOptions changes how the dictionary is exported to csv and csvc.
+func (x *Dictionary) SetCSVOptions(opts CSVOptions) error {
+	return x.dict.SetCsvOptions(opts)
+}
+
+func (x *Dictionary) Backups() int {
+	return x.dict.Backups()
+}
+
+// SetBackups makes ExportFile keep the last n versions of the files it
+// replaces as <file>.bak.1 (the newest) to <file>.bak.n.
+func (x *Dictionary) SetBackups(n int) {
+	x.dict.SetBackups(n)
+}
+
+// Import reads a dictionary in the given format from r and merges it into
+// the current one (an empty dictionary is simply replaced). The input is
+// decoded as it is read, so r is never loaded into memory as a whole.
+func (x *Dictionary) Import(r io.Reader, format Format) error {
+	return x.dict.Decode(stgpkg.SkipBOM(r), string(format), "reader")
+}
+
+// Export writes the dictionary to w in the given format, encoding it
+// incrementally.
+func (x *Dictionary) Export(w io.Writer, format Format) error {
+	return x.dict.Encode(w, string(format))
+}
+
+// ReadImport decodes a dictionary from r without changing the current one,
+// so the import can be previewed before it is applied, and a malformed
+// input never touches the dictionary.
+func (x *Dictionary) ReadImport(r io.Reader, format Format) (*PendingImport, error) {
+	graph, err := x.dict.DecodeGraph(stgpkg.SkipBOM(r), string(format))
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &PendingImport{dict: x.dict, graph: graph, source: "reader"}, nil
+}
+
+// ReadImportFile is ReadImport for a file on disk.
+func (x *Dictionary) ReadImportFile(path string, format Format) (*PendingImport, error) {
+	file, err := stgpkg.Open(path)
+
+	if err != nil {
+		return nil, err
+	}
+
+	defer file.Close()
+
+	pending, err := x.ReadImport(file, format)
+
+	if err != nil {
+		return nil, err
+	}
+
+	pending.source = path
+
+	return pending, nil
+}
+
+// Preview reports what Apply would change. With overwrite, the current
+// words are counted as removed instead of being merged with.
+func (p *PendingImport) Preview(overwrite bool) ImportPreview {
+	return p.dict.Preview(p.graph, overwrite)
+}
+
+// Apply merges the decoded dictionary into the current one, or with
+// overwrite replaces the current one, as a single change that one Undo
+// reverts. A pending import can only be applied once.
+func (p *PendingImport) Apply(overwrite bool) {
+	if p.graph == nil {
+		return
+	}
+
+	p.dict.Apply(p.graph, p.source, overwrite)
+	p.graph = nil
+}
+
+// ApplyIfEmpty is Apply for a dictionary that must be empty: it returns
+// ErrNotEmpty and changes nothing if any word was added since the import
+// was read.
+func (p *PendingImport) ApplyIfEmpty() error {
+	if p.graph == nil {
+		return nil
+	}
+
+	if err := p.dict.ApplyIfEmpty(p.graph, p.source); err != nil {
+		return err
+	}
+
+	p.graph = nil
+
+	return nil
+}
+
+// ImportFile is Import for a file on disk.
+func (x *Dictionary) ImportFile(path string, format Format) error {
+	return x.dict.Import(path, string(format))
+}
+
+// ExportFile is Export into a file on disk; CSV files get a UTF-8 BOM so
+// spreadsheet programs detect the encoding.
+func (x *Dictionary) ExportFile(path string, format Format) error {
+	return x.dict.Export(path, string(format))
+}